@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	fakeAPIUser     = "guest"
+	fakeAPIPassword = "guest"
+)
+
+// fakeAPI is a stand-in for the RabbitMQ management HTTP API. It serves canned JSON
+// responses for the endpoints buneary uses and records all requests it receives, so
+// that tests can verify the requests without a RabbitMQ server.
+//
+// Requests are answered with the registered response for the request URI or, if there
+// is none, for the request path. Without a registered response, GET requests result in
+// 404 Not Found like for unknown resources, and all other requests in 204 No Content.
+type fakeAPI struct {
+	t         *testing.T
+	server    *httptest.Server
+	mutex     sync.Mutex
+	responses map[string]fakeResponse
+	requests  []fakeRequest
+
+	// connections is the number of connections opened by clients.
+	connections int
+}
+
+// fakeResponse is a canned response of the fake API. body is encoded as JSON, except
+// for strings, which are sent as they are.
+type fakeResponse struct {
+	status int
+	body   interface{}
+}
+
+// fakeRequest is a request received by the fake API.
+type fakeRequest struct {
+	method string
+
+	// uri is the request URI as sent by the client, i.e. with escaped path segments
+	// and the query string.
+	uri string
+
+	user     string
+	password string
+	header   http.Header
+	body     []byte
+}
+
+// String returns the request method followed by the request URI.
+func (r fakeRequest) String() string {
+	return r.method + " " + r.uri
+}
+
+// newFakeAPI starts a new fake API server over TLS, like the RabbitMQ HTTP API that
+// buneary always connects to via HTTPS. The server is closed when the test finishes.
+func newFakeAPI(t *testing.T) *fakeAPI {
+	f := &fakeAPI{
+		t:         t,
+		responses: make(map[string]fakeResponse),
+	}
+
+	f.server = httptest.NewUnstartedServer(http.HandlerFunc(f.handle))
+
+	// AMQP clients dialling the server cause TLS handshake errors, which would be
+	// logged otherwise.
+	f.server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+
+	f.server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			f.mutex.Lock()
+			f.connections++
+			f.mutex.Unlock()
+		}
+	}
+
+	f.server.StartTLS()
+	t.Cleanup(f.server.Close)
+
+	f.respondDefaults()
+
+	return f
+}
+
+// respondDefaults registers responses for all read endpoints used by buneary. They
+// describe a server with a single node and a few resources in the default virtual
+// host `/`, which are also returned when listing the resources of all virtual hosts.
+func (f *fakeAPI) respondDefaults() {
+	exchanges := []object{
+		{"name": "", "vhost": "/", "type": "direct", "durable": true},
+		{"name": "orders", "vhost": "/", "type": "topic", "durable": true, "internal": false, "arguments": object{"alternate-exchange": "unrouted"}},
+	}
+
+	queues := []object{
+		{"name": "orders-eu", "vhost": "/", "durable": true, "arguments": object{"x-queue-type": "quorum"}, "messages": 3, "messages_unacknowledged": 1, "consumers": 2, "node": "rabbit@node-1", "policy": "ha", "message_stats": object{"publish_details": object{"rate": 1.5}}},
+		{"name": "amq.gen-1", "vhost": "/", "auto_delete": true, "arguments": object{}, "owner_pid_details": object{"name": "127.0.0.1:5000 -> 127.0.0.1:5672"}},
+	}
+
+	bindings := []object{
+		{"source": "orders", "vhost": "/", "destination": "orders-eu", "destination_type": "queue", "routing_key": "eu.*", "arguments": object{}},
+	}
+
+	policies := []object{
+		{"name": "ha", "vhost": "/", "pattern": "^orders", "apply-to": "queues", "priority": 1, "definition": object{"ha-mode": "all"}},
+	}
+
+	operatorPolicies := []object{
+		{"name": "limits", "vhost": "/", "pattern": ".*", "apply-to": "queues", "priority": 0, "definition": object{"max-length": 1000}},
+	}
+
+	consumers := []object{
+		{"consumer_tag": "ctag-1", "queue": object{"name": "orders-eu", "vhost": "/"}, "channel_details": object{"name": "127.0.0.1:5000 -> 127.0.0.1:5672 (1)", "connection_name": "127.0.0.1:5000 -> 127.0.0.1:5672"}, "ack_required": true, "prefetch_count": 10, "activity_status": "single_active"},
+	}
+
+	definitions := object{
+		"rabbit_version": "3.9.5",
+		"queues":         []object{{"name": "orders-eu", "vhost": "/", "durable": true}},
+	}
+
+	f.respond("GET", "/api/exchanges", http.StatusOK, object{"page": 1, "page_count": 1, "items": exchanges})
+	f.respond("GET", "/api/exchanges/%2F", http.StatusOK, object{"page": 1, "page_count": 1, "items": exchanges})
+	f.respond("GET", "/api/queues", http.StatusOK, object{"page": 1, "page_count": 1, "items": queues})
+	f.respond("GET", "/api/queues/%2F", http.StatusOK, object{"page": 1, "page_count": 1, "items": queues})
+	f.respond("GET", "/api/bindings", http.StatusOK, bindings)
+	f.respond("GET", "/api/bindings/%2F", http.StatusOK, bindings)
+	f.respond("GET", "/api/policies", http.StatusOK, policies)
+	f.respond("GET", "/api/policies/%2F", http.StatusOK, policies)
+	f.respond("GET", "/api/operator-policies", http.StatusOK, operatorPolicies)
+	f.respond("GET", "/api/operator-policies/%2F", http.StatusOK, operatorPolicies)
+	f.respond("GET", "/api/consumers", http.StatusOK, consumers)
+	f.respond("GET", "/api/consumers/%2F", http.StatusOK, consumers)
+	f.respond("GET", "/api/definitions", http.StatusOK, definitions)
+	f.respond("GET", "/api/definitions/%2F", http.StatusOK, definitions)
+
+	f.respond("GET", "/api/users", http.StatusOK, []object{
+		{"name": "guest", "password_hash": "hash", "hashing_algorithm": "rabbit_password_hashing_sha256", "tags": "administrator,monitoring"},
+		{"name": "reader", "password_hash": "", "tags": ""},
+	})
+
+	f.respond("GET", "/api/permissions", http.StatusOK, []object{
+		{"user": "guest", "vhost": "/", "configure": ".*", "write": ".*", "read": "^orders"},
+	})
+
+	f.respond("GET", "/api/vhosts", http.StatusOK, []object{
+		{"name": "/", "description": "Default virtual host", "tags": []string{"production"}, "default_queue_type": "quorum", "messages": 3, "messages_ready": 2, "messages_unacknowledged": 1},
+	})
+
+	f.respond("GET", "/api/connections", http.StatusOK, []object{
+		{"name": "127.0.0.1:5000 -> 127.0.0.1:5672", "user": "guest", "vhost": "/", "peer_host": "127.0.0.1", "peer_port": 5000, "state": "running", "channels": 1, "client_properties": object{"product": "buneary"}},
+		{"name": "127.0.0.1:5001 -> 127.0.0.1:5672", "user": "guest", "vhost": "staging", "peer_host": "127.0.0.1", "peer_port": 5001, "state": "running"},
+	})
+
+	f.respond("GET", "/api/channels", http.StatusOK, []object{
+		{"name": "127.0.0.1:5000 -> 127.0.0.1:5672 (1)", "number": 1, "user": "guest", "vhost": "/", "prefetch_count": 10, "consumer_count": 1, "messages_unacknowledged": 1, "connection_details": object{"name": "127.0.0.1:5000 -> 127.0.0.1:5672"}},
+	})
+
+	f.respond("POST", "/api/queues/%2F/orders-eu/get", http.StatusOK, []object{
+		{"payload_bytes": 5, "redelivered": false, "exchange": "orders", "routing_key": "eu.de", "properties": object{"headers": object{"region": "eu"}}, "headers": object{"region": "eu"}, "payload": "hello", "payload_encoding": "string"},
+		{"payload_bytes": 2, "redelivered": true, "exchange": "orders", "routing_key": "eu.fr", "payload": "/w==", "payload_encoding": "base64"},
+	})
+
+	f.respond("GET", "/api/overview", http.StatusOK, object{
+		"rabbitmq_version": "3.9.5",
+		"erlang_version":   "24.0.5",
+		"queue_totals":     object{"messages": 3, "messages_ready": 2, "messages_unacknowledged": 1},
+		"message_stats":    object{"publish_details": object{"rate": 1.5}, "deliver_get_details": object{"rate": 1}, "ack_details": object{"rate": 0.5}},
+	})
+
+	f.respond("GET", "/api/cluster-name", http.StatusOK, object{"name": "rabbit@node-1"})
+
+	f.respond("GET", "/api/nodes", http.StatusOK, []object{
+		{"name": "rabbit@node-1", "running": true, "mem_used": 100, "mem_limit": 1000, "disk_free": 5000, "disk_free_limit": 50, "fd_used": 10, "fd_total": 1024, "partitions": []string{}},
+	})
+
+	f.respond("GET", "/api/health/checks/alarms", http.StatusOK, object{"status": "ok"})
+	f.respond("GET", "/api/health/checks/virtual-hosts", http.StatusServiceUnavailable, object{"status": "failed", "reason": "vhost staging is down"})
+}
+
+// respond registers the response for the given method and path. The path has to be
+// escaped, e.g. `/api/queues/%2F/orders`, and may contain a query string.
+func (f *fakeAPI) respond(method, path string, status int, body interface{}) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.responses[method+" "+path] = fakeResponse{
+		status: status,
+		body:   body,
+	}
+}
+
+// provider returns a Provider connecting to the fake API with valid credentials and
+// the given virtual host.
+func (f *fakeAPI) provider(vhost string) Provider {
+	return f.providerWithCredentials(vhost, fakeAPIUser, fakeAPIPassword)
+}
+
+// providerWithCredentials returns a Provider connecting to the fake API as the given
+// user. The provider trusts the certificate of the fake API.
+func (f *fakeAPI) providerWithCredentials(vhost, user, password string) Provider {
+	b := buneary{
+		config:    f.config(vhost, user, password),
+		transport: f.server.Client().Transport,
+	}
+	return &b
+}
+
+// config returns the configuration for connecting to the fake API as the given user.
+func (f *fakeAPI) config(vhost, user, password string) *RabbitMQConfig {
+	return &RabbitMQConfig{
+		Address:  strings.TrimPrefix(f.server.URL, "https://"),
+		User:     user,
+		Password: password,
+		Vhost:    vhost,
+	}
+}
+
+// received returns all requests received so far and resets the recorded requests.
+func (f *fakeAPI) received() []fakeRequest {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	requests := f.requests
+	f.requests = nil
+
+	return requests
+}
+
+// handle records the request and writes the matching response.
+func (f *fakeAPI) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		f.t.Errorf("reading request body: %v", err)
+	}
+
+	user, password, _ := r.BasicAuth()
+
+	f.mutex.Lock()
+
+	f.requests = append(f.requests, fakeRequest{
+		method:   r.Method,
+		uri:      r.RequestURI,
+		user:     user,
+		password: password,
+		header:   r.Header,
+		body:     body,
+	})
+
+	// Like the RabbitMQ HTTP API, the fake API ignores trailing slashes, which are
+	// sent by rabbit-hole for some endpoints.
+	path := strings.TrimSuffix(r.URL.EscapedPath(), "/")
+
+	response, ok := f.responses[r.Method+" "+r.RequestURI]
+	if !ok {
+		response, ok = f.responses[r.Method+" "+path]
+	}
+
+	f.mutex.Unlock()
+
+	switch {
+	case user != fakeAPIUser || password != fakeAPIPassword:
+		response = fakeResponse{
+			status: http.StatusUnauthorized,
+			body:   map[string]string{"error": "not_authorised", "reason": "Login failed"},
+		}
+	case !ok && r.Method == "GET":
+		response = fakeResponse{
+			status: http.StatusNotFound,
+			body:   map[string]string{"error": "Object Not Found", "reason": "Not Found"},
+		}
+	case !ok:
+		response = fakeResponse{
+			status: http.StatusNoContent,
+		}
+	}
+
+	var encoded []byte
+
+	switch body := response.body.(type) {
+	case nil:
+	case string:
+		encoded = []byte(body)
+	default:
+		if encoded, err = json.Marshal(body); err != nil {
+			f.t.Errorf("encoding response body: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.status)
+	_, _ = w.Write(encoded)
+}
+
+// object is a shorthand for JSON objects in canned responses and expected requests.
+type object map[string]interface{}
@@ -3,11 +3,18 @@ package main
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
@@ -17,6 +24,10 @@ import (
 const (
 	amqpDefaultPort = 5672
 	apiDefaultPort  = 15672
+
+	// streamReadTimeout is the time to wait for the next message when reading from a
+	// stream. Since streams don't have an end, reading stops once this has elapsed.
+	streamReadTimeout = 2 * time.Second
 )
 
 type (
@@ -57,6 +68,10 @@ const (
 	// Quorum represents a quorum queue.
 	Quorum = "quorum"
 
+	// Stream represents a stream queue. Streams are append-only logs whose messages
+	// are not removed by consumers, but can be read repeatedly from any offset.
+	Stream = "stream"
+
 	// ToQueue represents a binding from an exchange to a queue.
 	ToQueue BindingType = "queue"
 
@@ -64,6 +79,74 @@ const (
 	ToExchange = "exchange"
 )
 
+var (
+	// exchangeTypes contains all exchange types built into RabbitMQ.
+	exchangeTypes = []ExchangeType{Direct, Headers, Fanout, Topic}
+
+	// queueTypes contains all queue types supported by buneary.
+	queueTypes = []QueueType{Classic, Quorum, Stream}
+
+	// namePattern matches valid exchange and queue names.
+	namePattern = regexp.MustCompile(`^[a-zA-Z0-9\-_.:]+$`)
+)
+
+// maxNameLength is the maximum length of exchange and queue names in bytes.
+const maxNameLength = 255
+
+// ParseExchangeType parses the given exchange type. Returns an error listing all valid
+// exchange types if the type isn't built into RabbitMQ. Exchange types provided by a
+// plugin, like `x-delayed-message`, have to be converted to ExchangeType directly.
+func ParseExchangeType(value string) (ExchangeType, error) {
+	for _, exchangeType := range exchangeTypes {
+		if value == string(exchangeType) {
+			return exchangeType, nil
+		}
+	}
+
+	valid := make([]string, len(exchangeTypes))
+	for i, exchangeType := range exchangeTypes {
+		valid[i] = string(exchangeType)
+	}
+
+	return "", fmt.Errorf("invalid exchange type %q, expected one of %s", value, strings.Join(valid, ", "))
+}
+
+// ParseQueueType parses the given queue type. Returns an error listing all valid queue
+// types if the type is unknown.
+func ParseQueueType(value string) (QueueType, error) {
+	for _, queueType := range queueTypes {
+		if value == string(queueType) {
+			return queueType, nil
+		}
+	}
+
+	valid := make([]string, len(queueTypes))
+	for i, queueType := range queueTypes {
+		valid[i] = string(queueType)
+	}
+
+	return "", fmt.Errorf("invalid queue type %q, expected one of %s", value, strings.Join(valid, ", "))
+}
+
+// ValidateName checks whether the given exchange or queue name is valid. A valid name
+// is not empty, at most 255 bytes long and only contains letters, digits, hyphens,
+// underscores, periods and colons.
+func ValidateName(name string) error {
+	if name == "" {
+		return errors.New("name must not be empty")
+	}
+
+	if len(name) > maxNameLength {
+		return fmt.Errorf("name must not be longer than %d bytes", maxNameLength)
+	}
+
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid name %q, only letters, digits, hyphens, underscores, periods and colons are allowed", name)
+	}
+
+	return nil
+}
+
 // Provider prescribes all functions a buneary implementation has to possess.
 type Provider interface {
 
@@ -73,13 +156,26 @@ type Provider interface {
 
 	// CreateQueue will create a new queue. If a queue with the provided name
 	// already exists, nothing will happen. CreateQueue will return the queue
-	// name generated by the server if no name has been provided.
+	// name generated by the server if no name has been provided, and the
+	// provided name otherwise.
 	CreateQueue(queue Queue) (string, error)
 
 	// CreateBinding will create a new binding. If a binding with the provided
 	// target already exists, nothing will happen.
 	CreateBinding(binding Binding) error
 
+	// CreateUser creates a new user. If a user with the provided name already
+	// exists, the user will be updated with the provided password and tags.
+	CreateUser(user User) error
+
+	// CreateVhost creates a new virtual host. If a virtual host with the provided
+	// name already exists, its description and tags will be updated.
+	CreateVhost(vhost Vhost) error
+
+	// CreatePolicy creates a new policy or operator policy in the configured virtual
+	// host. If a policy with the provided name already exists, it will be replaced.
+	CreatePolicy(policy Policy) error
+
 	// GetExchanges returns all exchanges that pass the provided filter function.
 	// To get all exchanges, pass a filter function that always returns true.
 	GetExchanges(filter func(exchange Exchange) bool) ([]Exchange, error)
@@ -88,10 +184,37 @@ type Provider interface {
 	// all queues, pass a filter function that always returns true.
 	GetQueues(filter func(queue Queue) bool) ([]Queue, error)
 
+	// StreamExchanges reads the exchanges matching the query page by page and passes
+	// each page of exchanges that pass the filter function to handle. If handle returns
+	// an error, no further pages will be read and the error will be returned.
+	StreamExchanges(query ListQuery, filter func(exchange Exchange) bool, handle func(exchanges []Exchange) error) error
+
+	// StreamQueues reads the queues matching the query page by page and passes each
+	// page of queues that pass the filter function to handle. If handle returns an
+	// error, no further pages will be read and the error will be returned.
+	StreamQueues(query ListQuery, filter func(queue Queue) bool, handle func(queues []Queue) error) error
+
 	// GetBindings returns all bindings that pass the provided filter function. To
 	// get all bindings, pass a filter function that always returns true.
 	GetBindings(filter func(binding Binding) bool) ([]Binding, error)
 
+	// GetUsers returns all users that pass the provided filter function. To get
+	// all users, pass a filter function that always returns true.
+	GetUsers(filter func(user User) bool) ([]User, error)
+
+	// GetPermissions returns all permissions that pass the provided filter function.
+	// To get all permissions, pass a filter function that always returns true.
+	GetPermissions(filter func(permissions Permissions) bool) ([]Permissions, error)
+
+	// GetVhosts returns all virtual hosts that pass the provided filter function. To
+	// get all virtual hosts, pass a filter function that always returns true.
+	GetVhosts(filter func(vhost Vhost) bool) ([]Vhost, error)
+
+	// GetPolicies returns all policies and operator policies that pass the provided
+	// filter function. To get all policies, pass a filter function that always
+	// returns true.
+	GetPolicies(filter func(policy Policy) bool) ([]Policy, error)
+
 	// GetMessages reads max messages from the given queue. The messages will be
 	// re-queued if requeue is set to true. Otherwise, they will be removed from
 	// the queue and thus won't be read by subscribers.
@@ -101,6 +224,19 @@ type Provider interface {
 	// an implementation should require the user opt-in to this behavior.
 	GetMessages(queue Queue, max int, requeue bool) ([]Message, error)
 
+	// GetStreamMessages reads max messages from the given stream queue, starting at
+	// the given offset. Unlike GetMessages, this won't alter the queue, since stream
+	// messages are not removed by consumers.
+	//
+	// The offset may be one of the strings `first`, `last` and `next`, a numeric
+	// offset as int64 or a point in time as time.Time. See ParseStreamOffset.
+	GetStreamMessages(queue Queue, offset interface{}, max int) ([]Message, error)
+
+	// GetDefinitions returns the server definitions in RabbitMQ's native format. If
+	// a virtual host is given, only the definitions of that virtual host will be
+	// returned. Otherwise, the definitions of the entire server are returned.
+	GetDefinitions(vhost string) (*Definitions, error)
+
 	// PublishMessage publishes a message to the given exchange. The exchange
 	// has to exist or must be created before the message is published.
 	//
@@ -108,13 +244,68 @@ type Provider interface {
 	// key is given, the message will be sent to the default exchange.
 	PublishMessage(message Message) error
 
+	// ImportDefinitions uploads the given definitions to the server. Existing
+	// resources with the same identity will be overwritten. If a virtual host is
+	// given, all definitions will be imported into that virtual host.
+	ImportDefinitions(vhost string, definitions *Definitions) error
+
+	// SetPermissions grants the given permissions to a user within a virtual host.
+	// Existing permissions of the user in that virtual host will be replaced.
+	SetPermissions(permissions Permissions) error
+
 	// DeleteExchange deletes the given exchange from the server. Will return
 	// an error if the specified exchange name doesn't exist.
-	DeleteExchange(exchange Exchange) error
+	//
+	// The server refuses to delete the exchange if DeleteConditions.IfUnused is set
+	// and the exchange is the source of any bindings.
+	DeleteExchange(exchange Exchange, conditions DeleteConditions) error
 
 	// DeleteQueue deletes the given queue from the server. Will return an error
 	// if the specified queue name doesn't exist.
-	DeleteQueue(queue Queue) error
+	//
+	// The server refuses to delete the queue if any of the given conditions isn't
+	// met, e.g. if DeleteConditions.IfEmpty is set and the queue holds messages.
+	DeleteQueue(queue Queue, conditions DeleteConditions) error
+
+	// PurgeQueue removes all messages that are ready for delivery from the given
+	// queue. Unacknowledged messages won't be removed.
+	PurgeQueue(queue Queue) error
+
+	// DeleteUser deletes the given user from the server, along with all of the
+	// user's permissions. Deleting a user that doesn't exist has no effect.
+	DeleteUser(user User) error
+
+	// DeleteVhost deletes the given virtual host from the server, including all of
+	// its exchanges, queues, messages and permissions.
+	//
+	// Since this can't be undone, an implementation should require the user to
+	// opt-in to this behavior.
+	DeleteVhost(vhost Vhost) error
+
+	// DeletePolicy deletes the given policy or operator policy from the configured
+	// virtual host. Only the policy name and the Operator field are required.
+	DeletePolicy(policy Policy) error
+
+	// GetConnections returns all client connections that pass the provided filter
+	// function. To get all connections, pass a filter function that always returns
+	// true.
+	GetConnections(filter func(connection Connection) bool) ([]Connection, error)
+
+	// GetChannels returns all channels that pass the provided filter function. To get
+	// all channels, pass a filter function that always returns true.
+	GetChannels(filter func(channel Channel) bool) ([]Channel, error)
+
+	// CloseConnection forcefully closes the given client connection. The reason will
+	// be sent to the client and appear in the server logs.
+	CloseConnection(connection Connection, reason string) error
+
+	// GetConsumers returns all consumers that pass the provided filter function. To
+	// get all consumers, pass a filter function that always returns true.
+	GetConsumers(filter func(consumer Consumer) bool) ([]Consumer, error)
+
+	// GetStatus returns an overview of the cluster along with all of its nodes and the
+	// results of the health checks supported by the server.
+	GetStatus() (*Status, error)
 }
 
 // RabbitMQConfig stores RabbitMQ-related configuration values.
@@ -129,6 +320,11 @@ type RabbitMQConfig struct {
 
 	// Password represents the password to authenticate with.
 	Password string
+
+	// Vhost is the virtual host to work with. If it is empty, resources will be
+	// created in and deleted from the default virtual host `/`, and listing them
+	// will return the resources of all virtual hosts.
+	Vhost string
 }
 
 // URI returns the AMQP URI for a configuration, prefixed with amqp://.
@@ -145,6 +341,11 @@ func (a *RabbitMQConfig) URI() string {
 
 	uri := fmt.Sprintf("amqp://%s:%s@%s:%s", a.User, a.Password, tokens[0], port)
 
+	// Without a path, the AMQP library connects to the default virtual host.
+	if a.Vhost != "" {
+		uri += "/" + url.PathEscape(a.Vhost)
+	}
+
 	return uri
 }
 
@@ -165,6 +366,15 @@ func (a *RabbitMQConfig) apiURI() string {
 	return uri
 }
 
+// vhost returns the configured virtual host. In case no virtual host has been
+// configured, the default virtual host `/` will be returned.
+func (a *RabbitMQConfig) vhost() string {
+	if a.Vhost == "" {
+		return "/"
+	}
+	return a.Vhost
+}
+
 // Exchange represents a RabbitMQ exchange.
 type Exchange struct {
 
@@ -192,6 +402,10 @@ type Exchange struct {
 	// operations related to the passed exchange. For instance, if NoWait is set to
 	// false when creating an exchange, the client won't wait for confirmation.
 	NoWait bool
+
+	// Arguments represents optional exchange arguments, such as the alternate
+	// exchange. They're used by some plugins and broker features.
+	Arguments map[string]interface{}
 }
 
 // Queue represents a message queue.
@@ -203,9 +417,10 @@ type Queue struct {
 	Name string
 
 	// Type is the type of the queue. Most users will only need classic queues, but
-	// buneary strives to support quorum queues as well.
+	// buneary strives to support quorum queues and streams as well.
 	//
-	// For more information, see https://www.rabbitmq.com/quorum-queues.html.
+	// For more information, see https://www.rabbitmq.com/quorum-queues.html and
+	// https://www.rabbitmq.com/streams.html.
 	Type QueueType
 
 	// Durable determines whether the queue will be persisted, i.e. be available after
@@ -216,6 +431,14 @@ type Queue struct {
 	// there are no consumers to ready from it left. It won't be deleted by default.
 	AutoDelete bool
 
+	// Exclusive indicates that the queue is owned by a single connection and will
+	// be deleted once that connection closes. This is only reported by the server.
+	Exclusive bool
+
+	// Arguments represents optional queue arguments like `x-message-ttl`. The queue
+	// type is stored as `x-queue-type` by the server.
+	Arguments map[string]interface{}
+
 	// Amount of messages in a queue
 	Messages int
 
@@ -227,6 +450,20 @@ type Queue struct {
 
 	// Memory being used by queue
 	Memory int64
+
+	// Policy is the name of the policy that currently applies to the queue, if any.
+	// This is only reported by the server.
+	Policy string
+
+	// Consumers is the amount of consumers subscribed to the queue.
+	Consumers int
+
+	// PublishRate is the amount of messages published to the queue per second.
+	PublishRate float64
+
+	// DeliverRate is the amount of messages delivered or fetched from the queue per
+	// second.
+	DeliverRate float64
 }
 
 // Binding represents an exchange- or queue binding.
@@ -251,6 +488,330 @@ type Binding struct {
 	// Key is the key of the binding. The key is crucial for message routing from the
 	// exchange to the bound queue or to another exchange.
 	Key string
+
+	// Arguments represents optional binding arguments. Bindings of headers exchanges
+	// use them for specifying the headers to match and the `x-match` mode.
+	Arguments map[string]interface{}
+}
+
+// User represents a RabbitMQ user of the internal authentication backend.
+type User struct {
+
+	// Name is the name of the user, which is used for authentication.
+	Name string
+
+	// Password is the plain-text password of the user. It is only used for creating
+	// users and never returned by the server. If neither Password nor PasswordHash is
+	// set, the user can only authenticate using mechanisms like X.509 certificates.
+	Password string
+
+	// PasswordHash is the salted and hashed password of the user. It can be used for
+	// creating a user instead of a plain-text password, e.g. when copying users.
+	PasswordHash string
+
+	// HashingAlgorithm is the algorithm PasswordHash has been created with. If it is
+	// empty, the server's default hashing algorithm is assumed.
+	HashingAlgorithm string
+
+	// Tags control the access to the management UI and HTTP API. Built-in tags are
+	// `administrator`, `monitoring`, `policymaker` and `management`.
+	Tags []string
+}
+
+// Permissions represents the permissions of a user within a virtual host. Each of the
+// permissions is a regular expression matching the names of the permitted resources.
+type Permissions struct {
+
+	// User is the name of the user the permissions are granted to.
+	User string
+
+	// Vhost is the virtual host the permissions apply to. If it is empty, the
+	// configured virtual host will be used when setting permissions.
+	Vhost string
+
+	// Configure matches the resources the user may create, delete or alter.
+	Configure string
+
+	// Write matches the resources the user may publish messages to.
+	Write string
+
+	// Read matches the resources the user may consume messages from.
+	Read string
+}
+
+// ListQuery restricts and orders the resources listed by the server. In contrast to
+// filter functions, it is evaluated by the server, so that large listings don't have
+// to be transferred entirely when only a few resources are needed.
+type ListQuery struct {
+
+	// Name only lets pass resources whose names contain Name. The comparison is case
+	// insensitive. If Regex is true, Name is a regular expression instead.
+	Name string
+
+	// Regex determines whether Name is a regular expression.
+	Regex bool
+
+	// SortBy is the field the resources are sorted by, e.g. `messages`. The names are
+	// the same as for filter expressions, but not all fields are supported. See
+	// exchangeSortKeys and queueSortKeys for the supported fields.
+	SortBy string
+
+	// Descending reverses the sort order.
+	Descending bool
+
+	// PageSize is the amount of resources per page, which defaults to the maximum of
+	// 500 resources.
+	PageSize int
+}
+
+// maxPageSize is the maximum page size accepted by the RabbitMQ HTTP API.
+const maxPageSize = 500
+
+// exchangeSortKeys maps the normalized names of all exchange fields that the server
+// can sort by to the corresponding RabbitMQ HTTP API fields.
+var exchangeSortKeys = map[string]string{
+	"name":       "name",
+	"type":       "type",
+	"durable":    "durable",
+	"autodelete": "auto_delete",
+	"internal":   "internal",
+}
+
+// queueSortKeys maps the normalized names of all queue fields that the server can
+// sort by to the corresponding RabbitMQ HTTP API fields.
+var queueSortKeys = map[string]string{
+	"name":          "name",
+	"durable":       "durable",
+	"autodelete":    "auto_delete",
+	"messages":      "messages",
+	"messagesunack": "messages_unacknowledged",
+	"node":          "node",
+	"memory":        "memory",
+	"policy":        "policy",
+	"consumers":     "consumers",
+}
+
+// params returns the RabbitMQ HTTP API query parameters for the query. sortKeys maps
+// the supported field names to the API fields, see queueSortKeys for an example.
+func (q ListQuery) params(sortKeys map[string]string) (url.Values, error) {
+	params := url.Values{}
+
+	pageSize := q.PageSize
+	if pageSize <= 0 || pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	params.Set("page_size", strconv.Itoa(pageSize))
+
+	if q.Name != "" {
+		params.Set("name", q.Name)
+		params.Set("use_regex", strconv.FormatBool(q.Regex))
+	}
+
+	if q.SortBy != "" {
+		key, ok := sortKeys[normalizeFieldName(q.SortBy)]
+		if !ok {
+			return nil, fmt.Errorf("the server can't sort by %q", q.SortBy)
+		}
+		params.Set("sort", key)
+		params.Set("sort_reverse", strconv.FormatBool(q.Descending))
+	}
+
+	return params, nil
+}
+
+// listPath returns the RabbitMQ HTTP API path for listing the given resource kind, e.g.
+// `queues`, in the given virtual host or in all virtual hosts if vhost is empty.
+func listPath(kind, vhost string, params url.Values) string {
+	path := "/api/" + kind
+
+	if vhost != "" {
+		path += "/" + url.PathEscape(vhost)
+	}
+
+	return path + "?" + params.Encode()
+}
+
+// DeleteConditions are safety conditions for deleting a resource. If a condition
+// isn't met, the server refuses to delete the resource and returns an error.
+type DeleteConditions struct {
+
+	// IfEmpty only deletes a queue if it doesn't hold any messages. This condition
+	// doesn't apply to exchanges.
+	IfEmpty bool
+
+	// IfUnused only deletes a queue if it doesn't have any consumers, or an exchange
+	// if it isn't the source of any bindings.
+	IfUnused bool
+}
+
+// params returns the conditions as query parameters for the RabbitMQ HTTP API.
+func (c DeleteConditions) params() url.Values {
+	params := url.Values{}
+
+	if c.IfEmpty {
+		params.Set("if-empty", "true")
+	}
+
+	if c.IfUnused {
+		params.Set("if-unused", "true")
+	}
+
+	return params
+}
+
+// Vhost represents a virtual host, which groups exchanges, queues and permissions.
+type Vhost struct {
+
+	// Name is the name of the virtual host. The default virtual host is called `/`.
+	Name string
+
+	// Description is an optional human-readable description of the virtual host.
+	Description string
+
+	// Tags is an optional list of tags for the virtual host.
+	Tags []string
+
+	// DefaultQueueType is the type of queues declared without an explicit type. It
+	// is only supported by RabbitMQ 3.9 and newer.
+	DefaultQueueType QueueType
+
+	// Messages is the total amount of messages in all queues of the virtual host.
+	Messages int
+
+	// MessagesReady is the amount of messages ready to be delivered.
+	MessagesReady int
+
+	// MessagesUnAck is the amount of messages waiting for an acknowledgement.
+	MessagesUnAck int
+}
+
+// Policy represents a policy, which adds arguments like `max-length` or `message-ttl`
+// to all queues or exchanges whose names match the policy pattern.
+type Policy struct {
+
+	// Name is the name of the policy.
+	Name string
+
+	// Vhost is the virtual host the policy is defined in. It is only reported by the
+	// server, new policies are created in the configured virtual host.
+	Vhost string
+
+	// Pattern is a regular expression matching the names of the queues or exchanges
+	// the policy applies to.
+	Pattern string
+
+	// ApplyTo determines whether the policy applies to `queues`, `exchanges` or `all`
+	// of them. Operator policies can only be applied to queues.
+	ApplyTo string
+
+	// Priority determines which policy applies if multiple policies match the same
+	// resource. The policy with the highest priority wins.
+	Priority int
+
+	// Definition contains the arguments added to all matching resources.
+	Definition map[string]interface{}
+
+	// Operator indicates an operator policy. Operator policies are set by operators
+	// to enforce limits. If both a policy and an operator policy match a resource,
+	// the lower value of each argument is used.
+	Operator bool
+}
+
+// Connection represents a client connection to the RabbitMQ server.
+type Connection struct {
+
+	// Name is the name of the connection generated by the server, which is required
+	// for closing the connection.
+	Name string
+
+	// User is the name of the user the client has authenticated as.
+	User string
+
+	// Vhost is the virtual host the client has connected to.
+	Vhost string
+
+	// PeerHost is the address of the client.
+	PeerHost string
+
+	// PeerPort is the port of the client.
+	PeerPort int
+
+	// State is the state of the connection, e.g. `running` or `blocked`.
+	State string
+
+	// Channels is the amount of channels opened on the connection.
+	Channels int
+
+	// ClientProperties contains the properties sent by the client library, such as
+	// `product`, `version` or `connection_name`.
+	ClientProperties map[string]interface{}
+}
+
+// Channel represents an AMQP channel within a client connection.
+type Channel struct {
+
+	// Name is the name of the channel generated by the server, which consists of the
+	// connection name and the channel number.
+	Name string
+
+	// Connection is the name of the connection the channel belongs to.
+	Connection string
+
+	// Number is the channel number within the connection.
+	Number int
+
+	// User is the name of the user that has opened the channel.
+	User string
+
+	// Vhost is the virtual host of the channel.
+	Vhost string
+
+	// Prefetch is the maximum amount of unacknowledged messages per consumer on the
+	// channel. Zero means that there is no limit.
+	Prefetch int
+
+	// Consumers is the amount of consumers on the channel.
+	Consumers int
+
+	// MessagesUnAck is the amount of messages delivered on the channel and waiting for
+	// an acknowledgement.
+	MessagesUnAck int
+}
+
+// Consumer represents a subscription of a client to a queue.
+type Consumer struct {
+
+	// Tag is the consumer tag, which identifies the consumer within its channel.
+	Tag string
+
+	// Queue is the name of the queue the consumer is subscribed to.
+	Queue string
+
+	// Vhost is the virtual host of the queue.
+	Vhost string
+
+	// Channel is the name of the channel the consumer has been created on.
+	Channel string
+
+	// Connection is the name of the connection the channel belongs to.
+	Connection string
+
+	// AckRequired indicates that the consumer acknowledges messages manually. If it's
+	// false, messages are considered acknowledged as soon as they're delivered.
+	AckRequired bool
+
+	// Prefetch is the maximum amount of unacknowledged messages for the consumer.
+	// Zero means that there is no limit.
+	Prefetch int
+
+	// Exclusive indicates that the consumer is the only one allowed on the queue.
+	Exclusive bool
+
+	// SingleActive indicates that the consumer is the single active consumer of a
+	// queue with `x-single-active-consumer` enabled. All other consumers of such a
+	// queue are waiting and don't receive any messages.
+	SingleActive bool
 }
 
 // Message represents a message to be enqueued.
@@ -274,6 +835,30 @@ type Message struct {
 	Body []byte
 }
 
+// ParseStreamOffset parses the given stream offset, which is either `first`, `last`,
+// `next`, a numeric offset, an RFC 3339 timestamp or a duration like `1h`. Durations
+// are relative to the current time, so `1h` denotes the messages of the last hour.
+func ParseStreamOffset(offset string) (interface{}, error) {
+	switch offset {
+	case "first", "last", "next":
+		return offset, nil
+	}
+
+	if number, err := strconv.ParseInt(offset, 10, 64); err == nil {
+		return number, nil
+	}
+
+	if timestamp, err := time.Parse(time.RFC3339, offset); err == nil {
+		return timestamp, nil
+	}
+
+	if duration, err := time.ParseDuration(offset); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+
+	return nil, fmt.Errorf("invalid stream offset %q, expected first, last, next, a number, a timestamp or a duration", offset)
+}
+
 // NewProvider initializes and returns a default Provider instance.
 func NewProvider(config *RabbitMQConfig) Provider {
 	b := buneary{
@@ -284,9 +869,18 @@ func NewProvider(config *RabbitMQConfig) Provider {
 
 // buneary is an implementation of the Provider interface with sane defaults.
 type buneary struct {
-	config  *RabbitMQConfig
-	channel *amqp.Channel
-	client  *rabbithole.Client
+	config      *RabbitMQConfig
+	channel     *amqp.Channel
+	client      *rabbithole.Client
+	clientMutex sync.Mutex
+
+	// transport is used for sending requests to the RabbitMQ HTTP API. If it is nil,
+	// setupClient creates a transport that accepts any TLS certificate.
+	transport http.RoundTripper
+
+	// httpClient is used for requests that rabbit-hole doesn't support. It shares the
+	// transport and its connections with the rabbit-hole client.
+	httpClient *http.Client
 }
 
 // setupChannel dials the configured RabbitMQ server, sets up a connection and opens a
@@ -294,17 +888,17 @@ type buneary struct {
 func (b *buneary) setupChannel() error {
 	if b.channel != nil {
 		if err := b.channel.Close(); err != nil {
-			return fmt.Errorf("closing AMQP channel: %w", err)
+			return fmt.Errorf("closing AMQP channel: %w", serverError(err))
 		}
 	}
 
 	conn, err := amqp.Dial(b.config.URI())
 	if err != nil {
-		return fmt.Errorf("dialling RabbitMQ server: %w", err)
+		return fmt.Errorf("dialling RabbitMQ server: %w", serverError(err))
 	}
 
 	if b.channel, err = conn.Channel(); err != nil {
-		return fmt.Errorf("establishing AMQP channel: %w", err)
+		return fmt.Errorf("establishing AMQP channel: %w", serverError(err))
 	}
 
 	return nil
@@ -312,55 +906,127 @@ func (b *buneary) setupChannel() error {
 
 // setupClient establishes a connection to the RabbitMQ HTTP API, initializing the
 // rabbit-hole client. It requires all connection data to exist in the configuration.
+//
+// The clients and their transport are only initialized once, so that all requests
+// share the same connection pool and setupClient is safe to be called by multiple
+// goroutines, e.g. when deleting multiple resources concurrently.
 func (b *buneary) setupClient() error {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	b.clientMutex.Lock()
+	defer b.clientMutex.Unlock()
+
+	if b.client != nil {
+		return nil
+	}
+
+	if b.transport == nil {
+		b.transport = &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
 	}
 
-	client, err := rabbithole.NewTLSClient(b.config.apiURI(), b.config.User, b.config.Password, transport)
+	client, err := rabbithole.NewTLSClient(b.config.apiURI(), b.config.User, b.config.Password, b.transport)
 	if err != nil {
 		return fmt.Errorf("creating rabbit-hole client: %w", err)
 	}
+
 	b.client = client
+	b.httpClient = &http.Client{
+		Transport: b.transport,
+	}
 
 	return nil
 }
 
 // CreateExchange creates the given exchange. See Provider.CreateExchange for details.
+//
+// rabbit-hole doesn't support declaring internal exchanges, so the request is sent
+// manually.
 func (b *buneary) CreateExchange(exchange Exchange) error {
-	if err := b.setupClient(); err != nil {
-		return err
+	// createExchangeRequestBody represents the HTTP request body for declaring an
+	// exchange.
+	type createExchangeRequestBody struct {
+		Type       string                 `json:"type"`
+		Durable    bool                   `json:"durable"`
+		AutoDelete bool                   `json:"auto_delete,omitempty"`
+		Internal   bool                   `json:"internal,omitempty"`
+		Arguments  map[string]interface{} `json:"arguments"`
 	}
 
-	_, err := b.client.DeclareExchange("/", exchange.Name, rabbithole.ExchangeSettings{
+	requestBody := createExchangeRequestBody{
 		Type:       string(exchange.Type),
 		Durable:    exchange.Durable,
 		AutoDelete: exchange.AutoDelete,
-	})
-	if err != nil {
-		return fmt.Errorf("declaring exchange: %w", err)
+		Internal:   exchange.Internal,
+		Arguments:  exchange.Arguments,
+	}
+
+	if requestBody.Arguments == nil {
+		requestBody.Arguments = make(map[string]interface{})
+	}
+
+	path := fmt.Sprintf("/api/exchanges/%s/%s", url.PathEscape(b.config.vhost()), url.PathEscape(exchange.Name))
+
+	if err := b.apiRequest("PUT", path, requestBody, nil); err != nil {
+		return fmt.Errorf("declaring exchange: %w", serverError(err))
 	}
 
 	return nil
 }
 
 // CreateQueue creates the given queue. See Provider.CreateQueue for details.
+//
+// The RabbitMQ HTTP API doesn't support creating queues without a name. Therefore,
+// server-named queues are declared via AMQP.
 func (b *buneary) CreateQueue(queue Queue) (string, error) {
+	if queue.Name == "" {
+		return b.declareServerNamedQueue(queue)
+	}
+
 	if err := b.setupClient(); err != nil {
 		return "", err
 	}
 
-	// ToDo: Fetch and return the generated queue name from the response.
-	_, err := b.client.DeclareQueue("/", queue.Name, rabbithole.QueueSettings{
+	_, err := b.client.DeclareQueue(b.config.vhost(), queue.Name, rabbithole.QueueSettings{
 		Type:       string(queue.Type),
 		Durable:    queue.Durable,
 		AutoDelete: queue.AutoDelete,
+		Arguments:  queue.Arguments,
 	})
 	if err != nil {
-		return "", fmt.Errorf("declaring queue: %w", err)
+		return "", fmt.Errorf("declaring queue: %w", serverError(err))
+	}
+
+	return queue.Name, nil
+}
+
+// declareServerNamedQueue declares the given queue via AMQP with an empty name, so
+// that the server generates a name for the queue. Returns the generated name.
+func (b *buneary) declareServerNamedQueue(queue Queue) (string, error) {
+	if err := b.setupChannel(); err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = b.Close()
+	}()
+
+	args := amqp.Table{}
+
+	for key, value := range queue.Arguments {
+		args[key] = value
+	}
+
+	// Unlike the HTTP API, AMQP expects the queue type to be a queue argument.
+	if queue.Type != "" {
+		args["x-queue-type"] = string(queue.Type)
+	}
+
+	declared, err := b.channel.QueueDeclare("", queue.Durable, queue.AutoDelete, false, false, args)
+	if err != nil {
+		return "", fmt.Errorf("declaring queue: %w", serverError(err))
 	}
 
-	return "", nil
+	return declared.Name, nil
 }
 
 // CreateBinding creates the given binding. See Provider.CreateBinding for details.
@@ -369,80 +1035,163 @@ func (b *buneary) CreateBinding(binding Binding) error {
 		return err
 	}
 
-	_, err := b.client.DeclareBinding("/", rabbithole.BindingInfo{
+	_, err := b.client.DeclareBinding(b.config.vhost(), rabbithole.BindingInfo{
 		Source:          binding.From.Name,
-		Vhost:           "/",
+		Vhost:           b.config.vhost(),
 		Destination:     binding.TargetName,
 		DestinationType: string(binding.Type),
 		RoutingKey:      binding.Key,
+		Arguments:       binding.Arguments,
 	})
 	if err != nil {
-		return fmt.Errorf("declaring binding: %w", err)
+		return fmt.Errorf("declaring binding: %w", serverError(err))
 	}
 
 	return nil
 }
 
 // GetExchanges returns exchanges passing the filter. See Provider.GetExchanges for details.
+//
+// The exchanges are read page by page using StreamExchanges with an empty ListQuery.
 func (b *buneary) GetExchanges(filter func(exchange Exchange) bool) ([]Exchange, error) {
-	if err := b.setupClient(); err != nil {
-		return nil, err
+	var exchanges []Exchange
+
+	err := b.StreamExchanges(ListQuery{}, filter, func(page []Exchange) error {
+		exchanges = append(exchanges, page...)
+		return nil
+	})
+
+	return exchanges, err
+}
+
+// StreamExchanges reads exchanges page by page. See Provider.StreamExchanges for details.
+//
+// rabbit-hole doesn't support paginating exchanges, so the requests are sent manually.
+func (b *buneary) StreamExchanges(query ListQuery, filter func(exchange Exchange) bool, handle func(exchanges []Exchange) error) error {
+	// pagedExchangesResponseBody represents a single page returned by the RabbitMQ API
+	// endpoint for listing exchanges (/api/exchanges).
+	type pagedExchangesResponseBody struct {
+		PageCount int                       `json:"page_count"`
+		Items     []rabbithole.ExchangeInfo `json:"items"`
 	}
 
-	exchangeInfos, err := b.client.ListExchanges()
+	params, err := query.params(exchangeSortKeys)
 	if err != nil {
-		return nil, fmt.Errorf("listing exchanges: %w", err)
+		return err
 	}
 
-	var exchanges []Exchange
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
 
-	for _, info := range exchangeInfos {
-		e := Exchange{
-			Name:       info.Name,
-			Type:       ExchangeType(info.Type),
-			Durable:    info.Durable,
-			AutoDelete: info.AutoDelete,
-			Internal:   info.Internal,
+		var responseBody pagedExchangesResponseBody
+
+		if err := b.apiRequest("GET", listPath("exchanges", b.config.Vhost, params), nil, &responseBody); err != nil {
+			return fmt.Errorf("listing exchanges: %w", serverError(err))
 		}
 
-		if filter(e) {
-			exchanges = append(exchanges, e)
+		var exchanges []Exchange
+
+		for _, info := range responseBody.Items {
+			e := Exchange{
+				Name:       info.Name,
+				Type:       ExchangeType(info.Type),
+				Durable:    info.Durable,
+				AutoDelete: info.AutoDelete,
+				Internal:   info.Internal,
+				Arguments:  info.Arguments,
+			}
+
+			if filter(e) {
+				exchanges = append(exchanges, e)
+			}
+		}
+
+		if len(exchanges) > 0 {
+			if err := handle(exchanges); err != nil {
+				return err
+			}
 		}
-	}
 
-	return exchanges, nil
+		if page >= responseBody.PageCount {
+			return nil
+		}
+	}
 }
 
 // GetQueues returns queues passing the filter. See Provider.GetQueues for details.
+//
+// The queues are read page by page using StreamQueues with an empty ListQuery.
 func (b *buneary) GetQueues(filter func(queue Queue) bool) ([]Queue, error) {
-	if err := b.setupClient(); err != nil {
-		return nil, err
-	}
+	var queues []Queue
+
+	err := b.StreamQueues(ListQuery{}, filter, func(page []Queue) error {
+		queues = append(queues, page...)
+		return nil
+	})
 
-	queueInfos, err := b.client.ListQueues()
+	return queues, err
+}
+
+// StreamQueues reads queues page by page. See Provider.StreamQueues for details.
+//
+// rabbit-hole only supports paginating the queues of all virtual hosts, so the requests
+// are sent manually. rabbithole.PagedQueueInfo is re-used for decoding the pages.
+func (b *buneary) StreamQueues(query ListQuery, filter func(queue Queue) bool, handle func(queues []Queue) error) error {
+	params, err := query.params(queueSortKeys)
 	if err != nil {
-		return nil, fmt.Errorf("listing queues: %w", err)
+		return err
 	}
 
-	var queues []Queue
+	for page := 1; ; page++ {
+		params.Set("page", strconv.Itoa(page))
 
-	for _, info := range queueInfos {
-		q := Queue{
-			Name:          info.Name,
-			Durable:       info.Durable,
-			AutoDelete:    info.AutoDelete,
-			Messages:      info.Messages,
-			MessagesUnAck: info.MessagesUnacknowledged,
-			Node:          info.Node,
-			Memory:        info.Memory,
+		var responseBody rabbithole.PagedQueueInfo
+
+		if err := b.apiRequest("GET", listPath("queues", b.config.Vhost, params), nil, &responseBody); err != nil {
+			return fmt.Errorf("listing queues: %w", serverError(err))
 		}
 
-		if filter(q) {
-			queues = append(queues, q)
+		var queues []Queue
+
+		for _, info := range responseBody.Items {
+			q := Queue{
+				Name:          info.Name,
+				Type:          Classic,
+				Durable:       info.Durable,
+				AutoDelete:    info.AutoDelete,
+				Exclusive:     info.OwnerPidDetails.Name != "",
+				Arguments:     info.Arguments,
+				Messages:      info.Messages,
+				MessagesUnAck: info.MessagesUnacknowledged,
+				Node:          info.Node,
+				Memory:        info.Memory,
+				Policy:        info.Policy,
+				Consumers:     info.Consumers,
+				PublishRate:   float64(info.MessageStats.PublishDetails.Rate),
+				DeliverRate:   float64(info.MessageStats.DeliverGetDetails.Rate),
+			}
+
+			// The server doesn't report the queue type directly but stores it as
+			// queue argument. Queues without that argument are classic queues.
+			if queueType, ok := info.Arguments["x-queue-type"].(string); ok {
+				q.Type = QueueType(queueType)
+			}
+
+			if filter(q) {
+				queues = append(queues, q)
+			}
 		}
-	}
 
-	return queues, nil
+		if len(queues) > 0 {
+			if err := handle(queues); err != nil {
+				return err
+			}
+		}
+
+		if page >= responseBody.PageCount {
+			return nil
+		}
+	}
 }
 
 // GetBindings returns bindings passing the filter. See Provider.GetBindings for details.
@@ -451,9 +1200,18 @@ func (b *buneary) GetBindings(filter func(binding Binding) bool) ([]Binding, err
 		return nil, err
 	}
 
-	bindingInfos, err := b.client.ListBindings()
+	var (
+		bindingInfos []rabbithole.BindingInfo
+		err          error
+	)
+
+	if b.config.Vhost != "" {
+		bindingInfos, err = b.client.ListBindingsIn(b.config.Vhost)
+	} else {
+		bindingInfos, err = b.client.ListBindings()
+	}
 	if err != nil {
-		return nil, fmt.Errorf("listing bindings: %w", err)
+		return nil, fmt.Errorf("listing bindings: %w", serverError(err))
 	}
 
 	var bindings []Binding
@@ -464,6 +1222,7 @@ func (b *buneary) GetBindings(filter func(binding Binding) bool) ([]Binding, err
 			From:       Exchange{Name: info.Source},
 			TargetName: info.Destination,
 			Key:        info.RoutingKey,
+			Arguments:  info.Arguments,
 		}
 
 		if filter(b) {
@@ -474,6 +1233,242 @@ func (b *buneary) GetBindings(filter func(binding Binding) bool) ([]Binding, err
 	return bindings, nil
 }
 
+// CreateUser creates the given user. See Provider.CreateUser for details.
+func (b *buneary) CreateUser(user User) error {
+	if err := b.setupClient(); err != nil {
+		return err
+	}
+
+	settings := rabbithole.UserSettings{
+		Name:             user.Name,
+		Tags:             strings.Join(user.Tags, ","),
+		Password:         user.Password,
+		PasswordHash:     user.PasswordHash,
+		HashingAlgorithm: rabbithole.HashingAlgorithm(user.HashingAlgorithm),
+	}
+
+	var err error
+
+	if user.Password == "" && user.PasswordHash == "" {
+		_, err = b.client.PutUserWithoutPassword(user.Name, settings)
+	} else {
+		_, err = b.client.PutUser(user.Name, settings)
+	}
+	if err != nil {
+		return fmt.Errorf("creating user: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// GetUsers returns users passing the filter. See Provider.GetUsers for details.
+func (b *buneary) GetUsers(filter func(user User) bool) ([]User, error) {
+	if err := b.setupClient(); err != nil {
+		return nil, err
+	}
+
+	userInfos, err := b.client.ListUsers()
+	if err != nil {
+		return nil, fmt.Errorf("listing users: %w", serverError(err))
+	}
+
+	var users []User
+
+	for _, info := range userInfos {
+		u := User{
+			Name:             info.Name,
+			PasswordHash:     info.PasswordHash,
+			HashingAlgorithm: string(info.HashingAlgorithm),
+		}
+
+		if info.Tags != "" {
+			u.Tags = strings.Split(info.Tags, ",")
+		}
+
+		if filter(u) {
+			users = append(users, u)
+		}
+	}
+
+	return users, nil
+}
+
+// GetPermissions returns permissions passing the filter. See Provider.GetPermissions
+// for details.
+func (b *buneary) GetPermissions(filter func(permissions Permissions) bool) ([]Permissions, error) {
+	if err := b.setupClient(); err != nil {
+		return nil, err
+	}
+
+	permissionInfos, err := b.client.ListPermissions()
+	if err != nil {
+		return nil, fmt.Errorf("listing permissions: %w", serverError(err))
+	}
+
+	var permissions []Permissions
+
+	for _, info := range permissionInfos {
+		p := Permissions{
+			User:      info.User,
+			Vhost:     info.Vhost,
+			Configure: info.Configure,
+			Write:     info.Write,
+			Read:      info.Read,
+		}
+
+		if filter(p) {
+			permissions = append(permissions, p)
+		}
+	}
+
+	return permissions, nil
+}
+
+// CreateVhost creates the given virtual host. See Provider.CreateVhost for details.
+//
+// rabbit-hole doesn't support virtual host metadata, so the request is sent manually.
+func (b *buneary) CreateVhost(vhost Vhost) error {
+	// createVhostRequestBody represents the HTTP request body for creating a vhost.
+	type createVhostRequestBody struct {
+		Description      string `json:"description,omitempty"`
+		Tags             string `json:"tags,omitempty"`
+		DefaultQueueType string `json:"default_queue_type,omitempty"`
+	}
+
+	requestBody := createVhostRequestBody{
+		Description:      vhost.Description,
+		Tags:             strings.Join(vhost.Tags, ","),
+		DefaultQueueType: string(vhost.DefaultQueueType),
+	}
+
+	if err := b.apiRequest("PUT", "/api/vhosts/"+url.PathEscape(vhost.Name), requestBody, nil); err != nil {
+		return fmt.Errorf("creating vhost: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// GetVhosts returns virtual hosts passing the filter. See Provider.GetVhosts for details.
+//
+// rabbit-hole doesn't support virtual host metadata, so the request is sent manually.
+func (b *buneary) GetVhosts(filter func(vhost Vhost) bool) ([]Vhost, error) {
+	// getVhostsResponseBody represents the HTTP response body returned by the RabbitMQ
+	// API endpoint for listing virtual hosts (/api/vhosts).
+	type getVhostsResponseBody []struct {
+		Name                   string   `json:"name"`
+		Description            string   `json:"description"`
+		Tags                   []string `json:"tags"`
+		DefaultQueueType       string   `json:"default_queue_type"`
+		Messages               int      `json:"messages"`
+		MessagesReady          int      `json:"messages_ready"`
+		MessagesUnacknowledged int      `json:"messages_unacknowledged"`
+	}
+
+	responseBody := getVhostsResponseBody{}
+
+	if err := b.apiRequest("GET", "/api/vhosts", nil, &responseBody); err != nil {
+		return nil, fmt.Errorf("listing vhosts: %w", serverError(err))
+	}
+
+	var vhosts []Vhost
+
+	for _, info := range responseBody {
+		v := Vhost{
+			Name:             info.Name,
+			Description:      info.Description,
+			Tags:             info.Tags,
+			DefaultQueueType: QueueType(info.DefaultQueueType),
+			Messages:         info.Messages,
+			MessagesReady:    info.MessagesReady,
+			MessagesUnAck:    info.MessagesUnacknowledged,
+		}
+
+		if filter(v) {
+			vhosts = append(vhosts, v)
+		}
+	}
+
+	return vhosts, nil
+}
+
+// CreatePolicy creates the given policy. See Provider.CreatePolicy for details.
+//
+// rabbit-hole doesn't support operator policies, so their request is sent manually.
+func (b *buneary) CreatePolicy(policy Policy) error {
+	if err := b.setupClient(); err != nil {
+		return err
+	}
+
+	policyInfo := rabbithole.Policy{
+		Pattern:    policy.Pattern,
+		ApplyTo:    policy.ApplyTo,
+		Priority:   policy.Priority,
+		Definition: policy.Definition,
+	}
+
+	var err error
+
+	if policy.Operator {
+		err = b.apiRequest("PUT", operatorPolicyPath(b.config.vhost(), policy.Name), policyInfo, nil)
+	} else {
+		_, err = b.client.PutPolicy(b.config.vhost(), policy.Name, policyInfo)
+	}
+	if err != nil {
+		return fmt.Errorf("creating policy: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// GetPolicies returns policies passing the filter. See Provider.GetPolicies for details.
+//
+// rabbit-hole doesn't support operator policies, so their request is sent manually.
+// Since they have the same representation as policies, rabbithole.Policy is re-used.
+func (b *buneary) GetPolicies(filter func(policy Policy) bool) ([]Policy, error) {
+	if err := b.setupClient(); err != nil {
+		return nil, err
+	}
+
+	var (
+		policyInfos         []rabbithole.Policy
+		operatorPolicyInfos []rabbithole.Policy
+		err                 error
+	)
+
+	if b.config.Vhost != "" {
+		policyInfos, err = b.client.ListPoliciesIn(b.config.Vhost)
+	} else {
+		policyInfos, err = b.client.ListPolicies()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing policies: %w", serverError(err))
+	}
+
+	if err := b.apiRequest("GET", operatorPolicyPath(b.config.Vhost, ""), nil, &operatorPolicyInfos); err != nil {
+		return nil, fmt.Errorf("listing operator policies: %w", serverError(err))
+	}
+
+	var policies []Policy
+
+	for i, info := range append(policyInfos, operatorPolicyInfos...) {
+		p := Policy{
+			Name:       info.Name,
+			Vhost:      info.Vhost,
+			Pattern:    info.Pattern,
+			ApplyTo:    info.ApplyTo,
+			Priority:   info.Priority,
+			Definition: info.Definition,
+			Operator:   i >= len(policyInfos),
+		}
+
+		if filter(p) {
+			policies = append(policies, p)
+		}
+	}
+
+	return policies, nil
+}
+
 // GetMessages reads messages from the given queue. See Provider.GetMessages for details.
 //
 // ToDo: Maybe move the function-scoped types somewhere else.
@@ -489,62 +1484,127 @@ func (b *buneary) GetMessages(queue Queue, max int, requeue bool) ([]Message, er
 	// getMessagesRequestBody represents the HTTP response body returned by the RabbitMQ
 	// API endpoint for reading messages from a queue (/api/queues/vhost/name/get).
 	type getMessagesResponseBody []struct {
-		PayloadBytes int                    `json:"payload_bytes"`
-		Redelivered  bool                   `json:"redelivered"`
-		Exchange     string                 `json:"exchange"`
-		RoutingKey   string                 `json:"routing_key"`
-		Headers      map[string]interface{} `json:"headers"`
-		Payload      string                 `json:"payload"`
+		PayloadBytes    int                    `json:"payload_bytes"`
+		Redelivered     bool                   `json:"redelivered"`
+		Exchange        string                 `json:"exchange"`
+		RoutingKey      string                 `json:"routing_key"`
+		Headers         map[string]interface{} `json:"headers"`
+		Payload         string                 `json:"payload"`
+		PayloadEncoding string                 `json:"payload_encoding"`
+	}
+
+	// Since RabbitMQ 3.7, the requeue field is ignored and only the ackmode counts.
+	// The requeue field is still sent for older servers.
+	ackmode := "ack_requeue_false"
+
+	if requeue {
+		ackmode = "ack_requeue_true"
 	}
 
 	requestBody := getMessagesRequestBody{
 		Count:    max,
 		Requeue:  requeue,
 		Encoding: "auto",
-		Ackmode:  "ack_requeue_true",
+		Ackmode:  ackmode,
 	}
 
-	requestBodyJson, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("marshalling request body: %w", err)
+	path := fmt.Sprintf("/api/queues/%s/%s/get", url.PathEscape(b.config.vhost()), url.PathEscape(queue.Name))
+
+	responseBody := getMessagesResponseBody{}
+
+	if err := b.apiRequest("POST", path, requestBody, &responseBody); err != nil {
+		return nil, fmt.Errorf("reading messages: %w", serverError(err))
 	}
 
-	uri := fmt.Sprintf("%s/api/queues/%%2F/%s/get", b.config.apiURI(), queue.Name)
+	messages := make([]Message, len(responseBody))
 
-	request, err := http.NewRequest("POST", uri, bytes.NewReader(requestBodyJson))
-	if err != nil {
-		return nil, fmt.Errorf("creating POST request: %w", err)
+	for i, m := range responseBody {
+		body := []byte(m.Payload)
+
+		// With the encoding set to auto, payloads that aren't valid UTF-8 are
+		// returned base64-encoded.
+		if m.PayloadEncoding == "base64" {
+			decoded, err := base64.StdEncoding.DecodeString(m.Payload)
+			if err != nil {
+				return nil, fmt.Errorf("decoding message payload: %w", err)
+			}
+			body = decoded
+		}
+
+		messages[i] = Message{
+			Target:     Exchange{Name: m.Exchange},
+			Headers:    m.Headers,
+			RoutingKey: m.RoutingKey,
+			Body:       body,
+		}
 	}
 
-	request.SetBasicAuth(b.config.User, b.config.Password)
+	return messages, nil
+}
 
-	response, err := (&http.Client{}).Do(request)
-	if err != nil {
-		return nil, err
+// GetDefinitions returns the server definitions. See Provider.GetDefinitions for details.
+func (b *buneary) GetDefinitions(vhost string) (*Definitions, error) {
+	definitions := &Definitions{}
+
+	if err := b.apiRequest("GET", definitionsPath(vhost), nil, definitions); err != nil {
+		return nil, fmt.Errorf("getting definitions: %w", serverError(err))
 	}
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("RabbitMQ server returned non-200 status: %s", response.Status)
+	return definitions, nil
+}
+
+// GetStreamMessages reads messages from the given stream. See Provider.GetStreamMessages
+// for details.
+func (b *buneary) GetStreamMessages(queue Queue, offset interface{}, max int) ([]Message, error) {
+	if err := b.setupChannel(); err != nil {
+		return nil, err
 	}
 
 	defer func() {
-		_ = response.Body.Close()
+		_ = b.Close()
 	}()
 
-	responseBody := getMessagesResponseBody{}
+	// Stream consumers are required to set a prefetch count and to acknowledge the
+	// messages, even though acknowledging won't remove them from the stream. The
+	// prefetch count is a 16-bit value, so larger values would wrap around.
+	prefetch := max
 
-	if err := json.NewDecoder(response.Body).Decode(&responseBody); err != nil {
-		return nil, err
+	if prefetch > math.MaxUint16 {
+		prefetch = math.MaxUint16
 	}
 
-	messages := make([]Message, len(responseBody))
+	if err := b.channel.Qos(prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("setting prefetch count: %w", serverError(err))
+	}
 
-	for i, m := range responseBody {
-		messages[i] = Message{
-			Target:     Exchange{Name: m.Exchange},
-			Headers:    m.Headers,
-			RoutingKey: m.RoutingKey,
-			Body:       []byte(m.Payload),
+	deliveries, err := b.channel.Consume(queue.Name, "", false, false, false, false, amqp.Table{
+		"x-stream-offset": offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("consuming stream: %w", serverError(err))
+	}
+
+	var messages []Message
+
+	for len(messages) < max {
+		select {
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return messages, nil
+			}
+
+			messages = append(messages, Message{
+				Target:     Exchange{Name: delivery.Exchange},
+				Headers:    delivery.Headers,
+				RoutingKey: delivery.RoutingKey,
+				Body:       delivery.Body,
+			})
+
+			if err := delivery.Ack(false); err != nil {
+				return nil, fmt.Errorf("acknowledging message: %w", serverError(err))
+			}
+		case <-time.After(streamReadTimeout):
+			return messages, nil
 		}
 	}
 
@@ -562,52 +1622,529 @@ func (b *buneary) PublishMessage(message Message) error {
 	}()
 
 	if err := b.channel.Publish(messageArgs(message)); err != nil {
-		return fmt.Errorf("publishing message: %w", err)
+		return fmt.Errorf("publishing message: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// ImportDefinitions uploads the given definitions. See Provider.ImportDefinitions for details.
+func (b *buneary) ImportDefinitions(vhost string, definitions *Definitions) error {
+	if err := b.apiRequest("POST", definitionsPath(vhost), definitions, nil); err != nil {
+		return fmt.Errorf("importing definitions: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// SetPermissions grants the given permissions. See Provider.SetPermissions for details.
+func (b *buneary) SetPermissions(permissions Permissions) error {
+	if err := b.setupClient(); err != nil {
+		return err
+	}
+
+	vhost := permissions.Vhost
+	if vhost == "" {
+		vhost = b.config.vhost()
+	}
+
+	_, err := b.client.UpdatePermissionsIn(vhost, permissions.User, rabbithole.Permissions{
+		Configure: permissions.Configure,
+		Write:     permissions.Write,
+		Read:      permissions.Read,
+	})
+	if err != nil {
+		return fmt.Errorf("setting permissions: %w", serverError(err))
 	}
 
 	return nil
 }
 
 // DeleteExchange deletes the given exchange. See Provider.DeleteExchange for details.
-func (b *buneary) DeleteExchange(exchange Exchange) error {
+//
+// rabbit-hole doesn't support the if-unused condition, so the request is sent manually.
+func (b *buneary) DeleteExchange(exchange Exchange, conditions DeleteConditions) error {
+	path := fmt.Sprintf("/api/exchanges/%s/%s", url.PathEscape(b.config.vhost()), url.PathEscape(exchange.Name))
+
+	// if-empty is only valid for queues, so it is never sent for exchanges.
+	conditions.IfEmpty = false
+
+	if params := conditions.params(); len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	if err := b.apiRequest("DELETE", path, nil, nil); err != nil {
+		return fmt.Errorf("deleting exchange: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// DeleteQueue deletes the given queue. See Provider.DeleteQueue for details.
+//
+// rabbit-hole doesn't support the if-empty and if-unused conditions, so the request
+// is sent manually.
+func (b *buneary) DeleteQueue(queue Queue, conditions DeleteConditions) error {
+	path := fmt.Sprintf("/api/queues/%s/%s", url.PathEscape(b.config.vhost()), url.PathEscape(queue.Name))
+
+	if params := conditions.params(); len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	if err := b.apiRequest("DELETE", path, nil, nil); err != nil {
+		return fmt.Errorf("deleting queue: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// PurgeQueue purges the given queue. See Provider.PurgeQueue for details.
+func (b *buneary) PurgeQueue(queue Queue) error {
 	if err := b.setupClient(); err != nil {
 		return err
 	}
 
-	_, err := b.client.DeleteExchange("/", exchange.Name)
+	_, err := b.client.PurgeQueue(b.config.vhost(), queue.Name)
 	if err != nil {
-		return fmt.Errorf("deleting exchange: %w", err)
+		return fmt.Errorf("purging queue: %w", serverError(err))
 	}
 
 	return nil
 }
 
-// DeleteQueue deletes the given exchange. See Provider.DeleteQueue for details.
-func (b *buneary) DeleteQueue(queue Queue) error {
+// DeleteUser deletes the given user. See Provider.DeleteUser for details.
+func (b *buneary) DeleteUser(user User) error {
 	if err := b.setupClient(); err != nil {
 		return err
 	}
 
-	_, err := b.client.DeleteQueue("/", queue.Name)
+	_, err := b.client.DeleteUser(user.Name)
 	if err != nil {
-		return fmt.Errorf("deleting queue: %w", err)
+		return fmt.Errorf("deleting user: %w", serverError(err))
 	}
 
 	return nil
 }
 
+// DeleteVhost deletes the given virtual host. See Provider.DeleteVhost for details.
+func (b *buneary) DeleteVhost(vhost Vhost) error {
+	if err := b.setupClient(); err != nil {
+		return err
+	}
+
+	_, err := b.client.DeleteVhost(vhost.Name)
+	if err != nil {
+		return fmt.Errorf("deleting vhost: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// DeletePolicy deletes the given policy. See Provider.DeletePolicy for details.
+//
+// rabbit-hole doesn't support operator policies, so their request is sent manually.
+func (b *buneary) DeletePolicy(policy Policy) error {
+	if err := b.setupClient(); err != nil {
+		return err
+	}
+
+	var err error
+
+	if policy.Operator {
+		err = b.apiRequest("DELETE", operatorPolicyPath(b.config.vhost(), policy.Name), nil, nil)
+	} else {
+		_, err = b.client.DeletePolicy(b.config.vhost(), policy.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("deleting policy: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// GetConnections returns connections passing the filter. See Provider.GetConnections
+// for details.
+func (b *buneary) GetConnections(filter func(connection Connection) bool) ([]Connection, error) {
+	if err := b.setupClient(); err != nil {
+		return nil, err
+	}
+
+	connectionInfos, err := b.client.ListConnections()
+	if err != nil {
+		return nil, fmt.Errorf("listing connections: %w", serverError(err))
+	}
+
+	var connections []Connection
+
+	for _, info := range connectionInfos {
+		// rabbit-hole can't list the connections of a single virtual host, so they
+		// have to be filtered on client-side.
+		if b.config.Vhost != "" && info.Vhost != b.config.Vhost {
+			continue
+		}
+
+		c := Connection{
+			Name:             info.Name,
+			User:             info.User,
+			Vhost:            info.Vhost,
+			PeerHost:         info.PeerHost,
+			PeerPort:         int(info.PeerPort),
+			State:            info.State,
+			Channels:         info.Channels,
+			ClientProperties: info.ClientProperties,
+		}
+
+		if filter(c) {
+			connections = append(connections, c)
+		}
+	}
+
+	return connections, nil
+}
+
+// GetChannels returns channels passing the filter. See Provider.GetChannels for details.
+func (b *buneary) GetChannels(filter func(channel Channel) bool) ([]Channel, error) {
+	if err := b.setupClient(); err != nil {
+		return nil, err
+	}
+
+	channelInfos, err := b.client.ListChannels()
+	if err != nil {
+		return nil, fmt.Errorf("listing channels: %w", serverError(err))
+	}
+
+	var channels []Channel
+
+	for _, info := range channelInfos {
+		// rabbit-hole can't list the channels of a single virtual host, so they have
+		// to be filtered on client-side.
+		if b.config.Vhost != "" && info.Vhost != b.config.Vhost {
+			continue
+		}
+
+		c := Channel{
+			Name:          info.Name,
+			Connection:    info.ConnectionDetails.Name,
+			Number:        info.Number,
+			User:          info.User,
+			Vhost:         info.Vhost,
+			Prefetch:      info.PrefetchCount,
+			Consumers:     info.ConsumerCount,
+			MessagesUnAck: info.UnacknowledgedMessageCount,
+		}
+
+		if filter(c) {
+			channels = append(channels, c)
+		}
+	}
+
+	return channels, nil
+}
+
+// CloseConnection closes the given connection. See Provider.CloseConnection for details.
+//
+// rabbit-hole doesn't support sending a reason, which is passed in the X-Reason header.
+// So in case a reason is given, the request is sent manually.
+func (b *buneary) CloseConnection(connection Connection, reason string) error {
+	if err := b.setupClient(); err != nil {
+		return err
+	}
+
+	var err error
+
+	if reason != "" {
+		path := "/api/connections/" + url.PathEscape(connection.Name)
+		err = b.apiRequestWithHeaders("DELETE", path, map[string]string{"X-Reason": reason}, nil, nil)
+	} else {
+		_, err = b.client.CloseConnection(connection.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("closing connection: %w", serverError(err))
+	}
+
+	return nil
+}
+
+// GetConsumers returns consumers passing the filter. See Provider.GetConsumers for details.
+//
+// rabbit-hole doesn't report whether a consumer is the single active consumer, so the
+// request is sent manually and rabbithole.ConsumerInfo is extended by that field.
+func (b *buneary) GetConsumers(filter func(consumer Consumer) bool) ([]Consumer, error) {
+	// getConsumersResponseBody represents the HTTP response body returned by the
+	// RabbitMQ API endpoint for listing consumers (/api/consumers).
+	type getConsumersResponseBody []struct {
+		rabbithole.ConsumerInfo
+		ActivityStatus string `json:"activity_status"`
+	}
+
+	path := "/api/consumers"
+
+	if b.config.Vhost != "" {
+		path += "/" + url.PathEscape(b.config.Vhost)
+	}
+
+	responseBody := getConsumersResponseBody{}
+
+	if err := b.apiRequest("GET", path, nil, &responseBody); err != nil {
+		return nil, fmt.Errorf("listing consumers: %w", serverError(err))
+	}
+
+	var consumers []Consumer
+
+	for _, info := range responseBody {
+		c := Consumer{
+			Tag:          info.ConsumerTag,
+			Queue:        info.Queue.Name,
+			Vhost:        info.Queue.Vhost,
+			Channel:      info.ChannelDetails.Name,
+			Connection:   info.ChannelDetails.ConnectionName,
+			AckRequired:  bool(info.AcknowledgementMode),
+			Prefetch:     info.PrefetchCount,
+			Exclusive:    info.Exclusive,
+			SingleActive: info.ActivityStatus == "single_active",
+		}
+
+		if filter(c) {
+			consumers = append(consumers, c)
+		}
+	}
+
+	return consumers, nil
+}
+
+// GetStatus returns the cluster status. See Provider.GetStatus for details.
+func (b *buneary) GetStatus() (*Status, error) {
+	if err := b.setupClient(); err != nil {
+		return nil, err
+	}
+
+	overview, err := b.client.Overview()
+	if err != nil {
+		return nil, fmt.Errorf("reading overview: %w", serverError(err))
+	}
+
+	clusterName, err := b.client.GetClusterName()
+	if err != nil {
+		return nil, fmt.Errorf("reading cluster name: %w", serverError(err))
+	}
+
+	nodeInfos, err := b.client.ListNodes()
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %w", serverError(err))
+	}
+
+	status := &Status{
+		ClusterName:     clusterName.Name,
+		RabbitMQVersion: overview.RabbitMQVersion,
+		ErlangVersion:   overview.ErlangVersion,
+		Messages:        overview.QueueTotals.Messages,
+		MessagesReady:   overview.QueueTotals.MessagesReady,
+		MessagesUnAck:   overview.QueueTotals.MessagesUnacknowledged,
+		PublishRate:     float64(overview.MessageStats.PublishDetails.Rate),
+		DeliverRate:     float64(overview.MessageStats.DeliverGetDetails.Rate),
+		AckRate:         float64(overview.MessageStats.AckDetails.Rate),
+	}
+
+	for _, info := range nodeInfos {
+		status.Nodes = append(status.Nodes, Node{
+			Name:                 info.Name,
+			Running:              info.IsRunning,
+			MemoryUsed:           int64(info.MemUsed),
+			MemoryLimit:          int64(info.MemLimit),
+			MemoryAlarm:          info.MemAlarm,
+			DiskFree:             int64(info.DiskFree),
+			DiskFreeLimit:        int64(info.DiskFreeLimit),
+			DiskFreeAlarm:        info.DiskFreeAlarm,
+			FileDescriptorsUsed:  info.FdUsed,
+			FileDescriptorsTotal: info.FdTotal,
+			Partitions:           info.Partitions,
+		})
+	}
+
+	for _, name := range healthChecks {
+		check, ok, err := b.healthCheck(name)
+		if err != nil {
+			return nil, fmt.Errorf("running health check %s: %w", name, serverError(err))
+		}
+		if ok {
+			status.HealthChecks = append(status.HealthChecks, check)
+		}
+	}
+
+	return status, nil
+}
+
+// healthCheck runs the health check with the given name. Passed checks are reported
+// with a 200 status code and failed checks with a 503 status code. If the server
+// doesn't support the health check, the returned bool is false.
+//
+// rabbit-hole doesn't support health checks, so the request is sent manually.
+func (b *buneary) healthCheck(name string) (HealthCheck, bool, error) {
+	// healthCheckResponseBody represents the HTTP response body returned by the
+	// RabbitMQ API endpoints for health checks (/api/health/checks/*).
+	type healthCheckResponseBody struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	}
+
+	response, err := b.sendAPIRequest("GET", "/api/health/checks/"+name, nil, nil)
+	if err != nil {
+		return HealthCheck{}, false, err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode == http.StatusNotFound {
+		return HealthCheck{}, false, nil
+	}
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusServiceUnavailable {
+		return HealthCheck{}, false, responseError(response)
+	}
+
+	var responseBody healthCheckResponseBody
+
+	if err := json.NewDecoder(response.Body).Decode(&responseBody); err != nil {
+		return HealthCheck{}, false, fmt.Errorf("decoding response body: %w", err)
+	}
+
+	check := HealthCheck{
+		Name:   name,
+		Passed: response.StatusCode == http.StatusOK && responseBody.Status == "ok",
+		Reason: responseBody.Reason,
+	}
+
+	return check, true, nil
+}
+
 // Close closes the AMQP channel to the configured RabbitMQ server. This function
 // should be called after running PublishMessage.
 func (b *buneary) Close() error {
 	if b.channel != nil {
 		if err := b.channel.Close(); err != nil {
-			return fmt.Errorf("closing AMQP channel: %w", err)
+			return fmt.Errorf("closing AMQP channel: %w", serverError(err))
 		}
 	}
 
 	return nil
 }
 
+// apiRequest sends a request to the given path of the RabbitMQ HTTP API. The request
+// body will be encoded as JSON unless it is nil, and the JSON response will be decoded
+// into result unless result is nil.
+//
+// This function is intended for API endpoints which aren't covered by rabbit-hole.
+func (b *buneary) apiRequest(method, path string, body interface{}, result interface{}) error {
+	return b.apiRequestWithHeaders(method, path, nil, body, result)
+}
+
+// apiRequestWithHeaders works like apiRequest, but sets the given additional headers.
+func (b *buneary) apiRequestWithHeaders(method, path string, headers map[string]string, body interface{}, result interface{}) error {
+	response, err := b.sendAPIRequest(method, path, headers, body)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return responseError(response)
+	}
+
+	if result == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return fmt.Errorf("decoding response body: %w", err)
+	}
+
+	return nil
+}
+
+// sendAPIRequest sends a request to the given path of the RabbitMQ HTTP API and returns
+// the response regardless of its status code. The caller has to close the body.
+func (b *buneary) sendAPIRequest(method, path string, headers map[string]string, body interface{}) (*http.Response, error) {
+	if err := b.setupClient(); err != nil {
+		return nil, err
+	}
+
+	var requestBody io.Reader
+
+	if body != nil {
+		requestBodyJson, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling request body: %w", err)
+		}
+		requestBody = bytes.NewReader(requestBodyJson)
+	}
+
+	request, err := http.NewRequest(method, b.config.apiURI()+path, requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating %s request: %w", method, err)
+	}
+
+	request.SetBasicAuth(b.config.User, b.config.Password)
+	request.Header.Set("Content-Type", "application/json")
+
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	return b.httpClient.Do(request)
+}
+
+// responseError returns a *ServerError for the given non-2xx response, using the
+// reason from the response body if there is one.
+func responseError(response *http.Response) error {
+	// errorResponseBody represents the HTTP response body returned by the RabbitMQ
+	// API in case of an error.
+	type errorResponseBody struct {
+		Error  string `json:"error"`
+		Reason string `json:"reason"`
+	}
+
+	var responseBody errorResponseBody
+
+	// The response body is only read to provide a more helpful error message, so an
+	// invalid or empty body is ignored.
+	_ = json.NewDecoder(response.Body).Decode(&responseBody)
+
+	message := "RabbitMQ server returned non-2xx status: " + response.Status
+
+	if responseBody.Reason != "" {
+		message += ": " + responseBody.Reason
+	}
+
+	return &ServerError{
+		Kind:   httpErrorKind(response.StatusCode, responseBody.Reason),
+		Code:   response.StatusCode,
+		Reason: responseBody.Reason,
+		Err:    errors.New(message),
+	}
+}
+
+// operatorPolicyPath returns the RabbitMQ HTTP API path for the operator policies in
+// the given virtual host, or for all operator policies if vhost is empty. If a name
+// is given, the path points to that particular operator policy.
+func operatorPolicyPath(vhost, name string) string {
+	path := "/api/operator-policies"
+
+	if vhost != "" {
+		path += "/" + url.PathEscape(vhost)
+	}
+
+	if name != "" {
+		path += "/" + url.PathEscape(name)
+	}
+
+	return path
+}
+
 // messageArgs returns all message fields expected by the AMQP library as single
 // values. This avoids large parameter lists when calling library functions.
 func messageArgs(message Message) (string, string, bool, bool, amqp.Publishing) {
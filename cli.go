@@ -2,36 +2,58 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/signal"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/yaml.v2"
 )
 
 var version = "UNDEFINED"
 
+// maxAgePattern matches the `x-max-age` values accepted by RabbitMQ for streams.
+var maxAgePattern = regexp.MustCompile(`^[0-9]+[YMDhms]$`)
+
 // globalOptions defines global command line options available for all commands.
 // They're read by the top-level command and passed to the sub-command factories.
 type globalOptions struct {
 	user     string
 	password string
+	vhost    string
+	dryRun   bool
 	out      io.StringWriter
+
+	// providerFactory creates the Provider used by the commands. If it is nil, NewProvider
+	// is used. Tests set this to a function returning an InMemoryProvider. In dry-run
+	// mode, read operations are still passed to the created Provider.
+	providerFactory func(config *RabbitMQConfig) Provider
 }
 
-// rootCommand creates the top-level `buneary` command without any functionality.
-func rootCommand() *cobra.Command {
-	options := globalOptions{
-		out: os.Stdout,
+// printSuccess prints the given success message. If the --dry-run flag is set, nothing
+// has been changed on the server, which is printed instead.
+func (o *globalOptions) printSuccess(message string) {
+	if o.dryRun {
+		message = "dry run: nothing was changed"
 	}
+	_, _ = o.out.WriteString(message + "\n")
+}
 
+// rootCommand creates the top-level `buneary` command without any functionality.
+func rootCommand(options *globalOptions) *cobra.Command {
 	root := &cobra.Command{
 		Use:   "buneary",
 		Short: "An easy-to-use CLI client for RabbitMQ.",
@@ -45,16 +67,30 @@ for managing exchanges, managing queues and publishing messages to exchanges.`,
 		},
 	}
 
-	root.AddCommand(createCommand(&options))
-	root.AddCommand(getCommand(&options))
-	root.AddCommand(publishCommand(&options))
-	root.AddCommand(deleteCommand(&options))
-	root.AddCommand(versionCommand(&options))
+	root.AddCommand(createCommand(options))
+	root.AddCommand(getCommand(options))
+	root.AddCommand(publishCommand(options))
+	root.AddCommand(deleteCommand(options))
+	root.AddCommand(setCommand(options))
+	root.AddCommand(closeCommand(options))
+	root.AddCommand(purgeCommand(options))
+	root.AddCommand(exportCommand(options))
+	root.AddCommand(definitionsCommand(options))
+	root.AddCommand(cloneCommand(options))
+	root.AddCommand(graphCommand(options))
+	root.AddCommand(routeCommand(options))
+	root.AddCommand(statusCommand(options))
+	root.AddCommand(uiCommand(options))
+	root.AddCommand(versionCommand(options))
 
 	root.PersistentFlags().
 		StringVarP(&options.user, "user", "u", "", "the username to connect with")
 	root.PersistentFlags().
 		StringVarP(&options.password, "password", "p", "", "the password to authenticate with")
+	root.PersistentFlags().
+		StringVar(&options.vhost, "vhost", "", "the virtual host to work with")
+	root.PersistentFlags().
+		BoolVar(&options.dryRun, "dry-run", false, "print the requests that would be sent instead of sending them")
 
 	return root
 }
@@ -72,6 +108,9 @@ func createCommand(options *globalOptions) *cobra.Command {
 	create.AddCommand(createExchangeCommand(options))
 	create.AddCommand(createQueueCommand(options))
 	create.AddCommand(createBindingCommand(options))
+	create.AddCommand(createUserCommand(options))
+	create.AddCommand(createVhostCommand(options))
+	create.AddCommand(createPolicyCommand(options))
 
 	return create
 }
@@ -83,6 +122,7 @@ type createExchangeOptions struct {
 	autoDelete bool
 	internal   bool
 	noWait     bool
+	customType bool
 }
 
 // createExchangeCommand creates the `buneary create exchange` command, making sure
@@ -109,6 +149,8 @@ func createExchangeCommand(options *globalOptions) *cobra.Command {
 		BoolVar(&createExchangeOptions.autoDelete, "auto-delete", false, "make the exchange auto-deleted")
 	createExchange.Flags().
 		BoolVar(&createExchangeOptions.internal, "internal", false, "make the exchange internal")
+	createExchange.Flags().
+		BoolVar(&createExchangeOptions.customType, "custom-type", false, "allow exchange types provided by plugins")
 
 	return createExchange
 }
@@ -117,46 +159,50 @@ func createExchangeCommand(options *globalOptions) *cobra.Command {
 // the configuration and calling the runCreateExchange function. In case the password
 // or both the user and password aren't provided, it will go into interactive mode.
 //
-// ToDo: Move the logic for parsing the exchange type into Exchange.
+// Only the exchange types built into RabbitMQ are accepted. Types provided by plugins,
+// such as `x-delayed-message`, require the --custom-type flag.
 func runCreateExchange(options *createExchangeOptions, args []string) error {
 	var (
 		address      = args[0]
 		name         = args[1]
-		exchangeType = args[2]
+		exchangeType = ExchangeType(args[2])
 	)
 
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	if !options.customType {
+		parsed, err := ParseExchangeType(args[2])
+		if err != nil {
+			return fmt.Errorf("%w (use --custom-type for plugin exchange types)", err)
+		}
+		exchangeType = parsed
+	}
+
 	user, password := getOrReadInCredentials(options.globalOptions)
 
-	provider := NewProvider(&RabbitMQConfig{
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
 		Address:  address,
 		User:     user,
 		Password: password,
+		Vhost:    options.vhost,
 	})
 
 	exchange := Exchange{
 		Name:       name,
+		Type:       exchangeType,
 		Durable:    options.durable,
 		AutoDelete: options.autoDelete,
 		Internal:   options.internal,
 		NoWait:     options.noWait,
 	}
 
-	switch exchangeType {
-	case "direct":
-		exchange.Type = Direct
-	case "headers":
-		exchange.Type = Headers
-	case "fanout":
-		exchange.Type = Fanout
-	case "topic":
-		exchange.Type = Topic
-	}
-
 	if err := provider.CreateExchange(exchange); err != nil {
 		return err
 	}
 
-	_, _ = options.out.WriteString("exchange created successfully\n")
+	options.printSuccess("exchange created successfully")
 
 	return nil
 }
@@ -164,24 +210,26 @@ func runCreateExchange(options *createExchangeOptions, args []string) error {
 // createQueueOptions defines options for creating a new queue.
 type createQueueOptions struct {
 	*globalOptions
-	durable    bool
-	autoDelete bool
+	durable        bool
+	autoDelete     bool
+	maxLengthBytes int64
+	maxAge         string
+	segmentSize    int64
 }
 
 // createQueueCommand creates the `buneary create queue` command, making sure that
-// exactly three arguments are passed.
-//
-// The <TYPE> argument may become optional for convenience in the future. In this
-// case, it should default to the classic queue type.
+// two or three arguments are passed. If only two arguments are passed, the optional
+// [NAME] argument is omitted and the server will generate a name. The <TYPE> argument
+// is always required, so that it can't be confused with the name.
 func createQueueCommand(options *globalOptions) *cobra.Command {
 	createQueueOptions := &createQueueOptions{
 		globalOptions: options,
 	}
 
 	createQueue := &cobra.Command{
-		Use:   "queue <ADDRESS> <NAME> <TYPE>",
+		Use:   "queue <ADDRESS> [NAME] <TYPE>",
 		Short: "Create a new queue",
-		Args:  cobra.ExactArgs(3),
+		Args:  cobra.RangeArgs(2, 3),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runCreateQueue(createQueueOptions, args)
 		},
@@ -191,6 +239,12 @@ func createQueueCommand(options *globalOptions) *cobra.Command {
 		BoolVar(&createQueueOptions.durable, "durable", false, "make the queue durable")
 	createQueue.Flags().
 		BoolVar(&createQueueOptions.autoDelete, "auto-delete", false, "make the queue auto-deleted")
+	createQueue.Flags().
+		Int64Var(&createQueueOptions.maxLengthBytes, "max-length-bytes", 0, "the maximum size of the queue in bytes")
+	createQueue.Flags().
+		StringVar(&createQueueOptions.maxAge, "max-age", "", "the maximum age of stream messages, e.g. 7D")
+	createQueue.Flags().
+		Int64Var(&createQueueOptions.segmentSize, "segment-size", 0, "the size of stream segment files in bytes")
 
 	return createQueue
 }
@@ -199,43 +253,78 @@ func createQueueCommand(options *globalOptions) *cobra.Command {
 // configuration and calling the CreateQueue function. In case the password or both
 // the user and password aren't provided, it will go into interactive mode.
 //
-// If the queue type is empty or invalid, the queue type defaults to Classic.
+// Streams are always durable, and only they support the --max-age and --segment-size
+// flags. An invalid queue type or queue name results in an error. If the queue name
+// is omitted or empty, the name generated by the server will be printed.
 func runCreateQueue(options *createQueueOptions, args []string) error {
 	var (
 		address   = args[0]
-		name      = args[1]
-		queueType = args[2]
+		name      string
+		queueType = args[len(args)-1]
 	)
 
+	if len(args) == 3 {
+		name = args[1]
+	}
+
+	if name != "" {
+		if err := ValidateName(name); err != nil {
+			return err
+		}
+	}
+
+	parsedType, err := ParseQueueType(queueType)
+	if err != nil {
+		return err
+	}
+
 	user, password := getOrReadInCredentials(options.globalOptions)
 
-	provider := NewProvider(&RabbitMQConfig{
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
 		Address:  address,
 		User:     user,
 		Password: password,
+		Vhost:    options.vhost,
 	})
 
 	queue := Queue{
 		Name:       name,
-		Durable:    options.durable,
+		Type:       parsedType,
+		Durable:    options.durable || parsedType == Stream,
 		AutoDelete: options.autoDelete,
+		Arguments:  make(map[string]interface{}),
 	}
 
-	switch queueType {
-	case "quorum":
-		queue.Type = Quorum
-	case "classic":
-		fallthrough
-	default:
-		queue.Type = Classic
+	if queue.Type != Stream && (options.maxAge != "" || options.segmentSize != 0) {
+		return errors.New("--max-age and --segment-size are only supported for streams")
+	}
+
+	if options.maxLengthBytes != 0 {
+		queue.Arguments["x-max-length-bytes"] = options.maxLengthBytes
+	}
+
+	if options.maxAge != "" {
+		if !maxAgePattern.MatchString(options.maxAge) {
+			return fmt.Errorf("invalid max age %q, expected a number and one of the units Y, M, D, h, m, s", options.maxAge)
+		}
+		queue.Arguments["x-max-age"] = options.maxAge
 	}
 
-	_, err := provider.CreateQueue(queue)
+	if options.segmentSize != 0 {
+		queue.Arguments["x-stream-max-segment-size-bytes"] = options.segmentSize
+	}
+
+	createdName, err := provider.CreateQueue(queue)
 	if err != nil {
 		return err
 	}
 
-	_, _ = options.out.WriteString("queue created successfully\n")
+	if name == "" {
+		options.printSuccess(fmt.Sprintf("queue %s created successfully", createdName))
+		return nil
+	}
+
+	options.printSuccess("queue created successfully")
 
 	return nil
 }
@@ -244,6 +333,8 @@ func runCreateQueue(options *createQueueOptions, args []string) error {
 type createBindingOptions struct {
 	*globalOptions
 	toExchange bool
+	arguments  []string
+	match      string
 }
 
 // createBindingCommand creates the `buneary create binding` command, making sure
@@ -264,6 +355,10 @@ func createBindingCommand(options *globalOptions) *cobra.Command {
 
 	createQueue.Flags().
 		BoolVar(&createBindingOptions.toExchange, "to-exchange", false, "the target is another exchange")
+	createQueue.Flags().
+		StringArrayVar(&createBindingOptions.arguments, "arg", nil, "a binding argument in the form key=value")
+	createQueue.Flags().
+		StringVar(&createBindingOptions.match, "match", "", "the x-match mode for headers exchanges, all or any")
 
 	return createQueue
 }
@@ -273,7 +368,8 @@ func createBindingCommand(options *globalOptions) *cobra.Command {
 // both the user and password aren't provided, it will go into interactive mode.
 //
 // The binding type defaults to ToQueue. To create a binding to another exchange, the
-// --to-exchange flag has to be used.
+// --to-exchange flag has to be used. Bindings of headers exchanges require the header
+// values as --arg flags, while --match determines whether all or any of them must match.
 func runCreateBinding(options *createBindingOptions, args []string) error {
 	var (
 		address    = args[0]
@@ -282,18 +378,32 @@ func runCreateBinding(options *createBindingOptions, args []string) error {
 		bindingKey = args[3]
 	)
 
+	arguments, err := parseArguments(options.arguments)
+	if err != nil {
+		return err
+	}
+
+	if options.match != "" {
+		if options.match != "all" && options.match != "any" {
+			return fmt.Errorf("invalid match mode %q, expected one of all, any", options.match)
+		}
+		arguments["x-match"] = options.match
+	}
+
 	user, password := getOrReadInCredentials(options.globalOptions)
 
-	provider := NewProvider(&RabbitMQConfig{
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
 		Address:  address,
 		User:     user,
 		Password: password,
+		Vhost:    options.vhost,
 	})
 
 	binding := Binding{
 		From:       Exchange{Name: name},
 		TargetName: target,
 		Key:        bindingKey,
+		Arguments:  arguments,
 	}
 
 	switch options.toExchange {
@@ -307,555 +417,3109 @@ func runCreateBinding(options *createBindingOptions, args []string) error {
 		return err
 	}
 
-	_, _ = options.out.WriteString("queue created successfully\n")
+	options.printSuccess("queue created successfully")
 
 	return nil
 }
 
-// getCommand creates the `buneary get` command without any functionality.
-func getCommand(options *globalOptions) *cobra.Command {
-	get := &cobra.Command{
-		Use:   "get <COMMAND>",
-		Short: "Get a resource",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return cmd.Help()
-		},
-	}
-
-	get.AddCommand(getExchangesCommand(options))
-	get.AddCommand(getExchangeCommand(options))
-	get.AddCommand(getQueuesCommand(options))
-	get.AddCommand(getQueueCommand(options))
-	get.AddCommand(getBindingsCommand(options))
-	get.AddCommand(getBindingCommand(options))
-	get.AddCommand(getMessagesCommand(options))
-
-	return get
+// createUserOptions defines options for creating a new user.
+type createUserOptions struct {
+	*globalOptions
+	newPassword      string
+	passwordHash     string
+	hashingAlgorithm string
+	tags             []string
 }
 
-// getExchangesCommand creates the `buneary get exchanges` command, making sure that
-// exactly one argument is passed.
-func getExchangesCommand(options *globalOptions) *cobra.Command {
-	getExchanges := &cobra.Command{
-		Use:   "exchanges <ADDRESS>",
-		Short: "Get all available exchanges",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGetExchanges(options, args)
-		},
+// createUserCommand creates the `buneary create user` command, making sure that
+// exactly two arguments are passed.
+func createUserCommand(options *globalOptions) *cobra.Command {
+	createUserOptions := &createUserOptions{
+		globalOptions: options,
 	}
 
-	return getExchanges
-}
-
-// getExchangeCommand creates the `buneary get exchange` command, making sure that exactly
-// two arguments are passed.
-func getExchangeCommand(options *globalOptions) *cobra.Command {
-	getExchange := &cobra.Command{
-		Use:   "exchange <ADDRESS> <NAME>",
-		Short: "Get a single exchange",
+	createUser := &cobra.Command{
+		Use:   "user <ADDRESS> <NAME>",
+		Short: "Create a new user",
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGetExchanges(options, args)
+			return runCreateUser(createUserOptions, args)
 		},
 	}
 
-	return getExchange
+	createUser.Flags().
+		StringVar(&createUserOptions.newPassword, "new-password", "", "the password of the new user")
+	createUser.Flags().
+		StringVar(&createUserOptions.passwordHash, "password-hash", "", "the salted password hash of the new user")
+	createUser.Flags().
+		StringVar(&createUserOptions.hashingAlgorithm, "hashing-algorithm", "", "the algorithm of the password hash, sha256 or sha512")
+	createUser.Flags().
+		StringSliceVar(&createUserOptions.tags, "tags", nil, "comma-separated user tags, e.g. management")
+
+	return createUser
 }
 
-// runGetExchanges either returns all exchanges or - if an exchange name has been
-// specified as second argument - a single exchange. In case the password or both
+// runCreateUser creates a new user by reading the command line data, setting the
+// configuration and calling the CreateUser function. In case the password or both
 // the user and password aren't provided, it will go into interactive mode.
 //
-// This flexibility allows runGetExchanges to be used by both `buneary get exchanges`
-// as well as `buneary get exchange`.
-func runGetExchanges(options *globalOptions, args []string) error {
+// The --password flag is reserved for authenticating, so the password of the new
+// user has to be specified using --new-password or --password-hash. Without any of
+// them, the user won't be able to authenticate with a password.
+func runCreateUser(options *createUserOptions, args []string) error {
 	var (
 		address = args[0]
+		name    = args[1]
 	)
 
-	user, password := getOrReadInCredentials(options)
-
-	provider := NewProvider(&RabbitMQConfig{
-		Address:  address,
-		User:     user,
-		Password: password,
-	})
+	if options.newPassword != "" && options.passwordHash != "" {
+		return errors.New("--new-password and --password-hash are mutually exclusive")
+	}
 
-	// The default filter will let pass all exchanges regardless of their names.
-	filter := func(_ Exchange) bool {
-		return true
+	newUser := User{
+		Name:         name,
+		Password:     options.newPassword,
+		PasswordHash: options.passwordHash,
+		Tags:         options.tags,
 	}
 
-	// However, if an exchange name has been specified as second argument, only
-	// that particular exchange should be returned.
-	if len(args) > 1 {
-		filter = func(exchange Exchange) bool {
-			return exchange.Name == args[1]
+	if options.hashingAlgorithm != "" {
+		if options.hashingAlgorithm != "sha256" && options.hashingAlgorithm != "sha512" {
+			return fmt.Errorf("invalid hashing algorithm %q, expected one of sha256, sha512", options.hashingAlgorithm)
 		}
+		newUser.HashingAlgorithm = "rabbit_password_hashing_" + options.hashingAlgorithm
 	}
 
-	exchanges, err := provider.GetExchanges(filter)
-	if err != nil {
-		return err
-	}
+	user, password := getOrReadInCredentials(options.globalOptions)
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name", "Type", "Durable", "Auto-Delete", "Internal"})
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
 
-	for _, exchange := range exchanges {
-		row := make([]string, 5)
-		row[0] = exchange.Name
-		row[1] = string(exchange.Type)
-		row[2] = boolToString(exchange.Durable)
-		row[3] = boolToString(exchange.AutoDelete)
-		row[4] = boolToString(exchange.Internal)
-		table.Append(row)
+	if err := provider.CreateUser(newUser); err != nil {
+		return err
 	}
 
-	table.Render()
+	options.printSuccess("user created successfully")
 
 	return nil
 }
 
-// getQueuesCommand creates the `buneary get queues` command, making sure that
-// exactly one argument is passed.
-func getQueuesCommand(options *globalOptions) *cobra.Command {
-	getQueues := &cobra.Command{
-		Use:   "queues <ADDRESS>",
-		Short: "Get all available queues",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGetQueues(options, args)
-		},
-	}
-
-	return getQueues
+// createVhostOptions defines options for creating a new virtual host.
+type createVhostOptions struct {
+	*globalOptions
+	description      string
+	tags             []string
+	defaultQueueType string
 }
 
-// getQueueCommand creates the `buneary get queue` command, making sure that exactly two
-// arguments are passed.
-func getQueueCommand(options *globalOptions) *cobra.Command {
-	getQueue := &cobra.Command{
-		Use:   "queue <ADDRESS> <NAME>",
-		Short: "Get a single queue",
+// createVhostCommand creates the `buneary create vhost` command, making sure that
+// exactly two arguments are passed.
+func createVhostCommand(options *globalOptions) *cobra.Command {
+	createVhostOptions := &createVhostOptions{
+		globalOptions: options,
+	}
+
+	createVhost := &cobra.Command{
+		Use:   "vhost <ADDRESS> <NAME>",
+		Short: "Create a new virtual host",
 		Args:  cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGetQueues(options, args)
+			return runCreateVhost(createVhostOptions, args)
 		},
 	}
 
-	return getQueue
+	createVhost.Flags().
+		StringVar(&createVhostOptions.description, "description", "", "a description of the virtual host")
+	createVhost.Flags().
+		StringSliceVar(&createVhostOptions.tags, "tags", nil, "comma-separated virtual host tags")
+	createVhost.Flags().
+		StringVar(&createVhostOptions.defaultQueueType, "default-queue-type", "", "the default queue type: classic, quorum or stream")
+
+	return createVhost
 }
 
-// runGetQueues either returns all queues or - if a queue name has been specified as second
-// argument - a single queue. In case the password or both the user and password aren't
-// provided, it will go into interactive mode.
-//
-// This flexibility allows runGetQueues to be used by both `buneary get queues` as well as
-// `buneary get queue`.
-func runGetQueues(options *globalOptions, args []string) error {
+// runCreateVhost creates a new virtual host by reading the command line data, setting
+// the configuration and calling the CreateVhost function. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+func runCreateVhost(options *createVhostOptions, args []string) error {
 	var (
 		address = args[0]
+		name    = args[1]
 	)
 
-	user, password := getOrReadInCredentials(options)
-
-	provider := NewProvider(&RabbitMQConfig{
-		Address:  address,
-		User:     user,
-		Password: password,
-	})
-
-	// The default filter will let pass all queues regardless of their names.
-	filter := func(_ Queue) bool {
-		return true
+	vhost := Vhost{
+		Name:        name,
+		Description: options.description,
+		Tags:        options.tags,
 	}
 
-	// However, if a queue name has been specified as second argument, only that
-	// particular queue should be returned.
-	if len(args) > 1 {
-		filter = func(queue Queue) bool {
-			return queue.Name == args[1]
+	if options.defaultQueueType != "" {
+		queueType, err := ParseQueueType(options.defaultQueueType)
+		if err != nil {
+			return err
 		}
+		vhost.DefaultQueueType = queueType
 	}
 
-	queues, err := provider.GetQueues(filter)
-	if err != nil {
-		return err
-	}
+	user, password := getOrReadInCredentials(options.globalOptions)
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Name", "Durable", "Auto-Delete", "Leader", "Messages", "MessagesUnAck", "Memory"})
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
 
-	for _, queue := range queues {
-		row := make([]string, 7)
-		row[0] = queue.Name
-		row[1] = boolToString(queue.Durable)
-		row[2] = boolToString(queue.AutoDelete)
-		row[3] = queue.Node
-		row[4] = strconv.Itoa(queue.Messages)
-		row[5] = strconv.Itoa(queue.MessagesUnAck)
-		row[6] = strconv.FormatInt(queue.Memory, 10)
-		table.Append(row)
+	if err := provider.CreateVhost(vhost); err != nil {
+		return err
 	}
 
-	table.Render()
+	options.printSuccess("vhost created successfully")
 
 	return nil
 }
 
-// getBindingsCommand creates the `buneary get bindings` command, making sure that
-// exactly one argument is passed.
-func getBindingsCommand(options *globalOptions) *cobra.Command {
-	getQueues := &cobra.Command{
-		Use:   "bindings <ADDRESS>",
-		Short: "Get all available bindings",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGetBindings(options, args)
-		},
-	}
-
-	return getQueues
+// createPolicyOptions defines options for creating a new policy.
+type createPolicyOptions struct {
+	*globalOptions
+	applyTo  string
+	priority int
+	operator bool
 }
 
-// getBindingCommand creates the `buneary get binding` command, making sure that exactly
-// three arguments are passed.
-func getBindingCommand(options *globalOptions) *cobra.Command {
-	getQueue := &cobra.Command{
-		Use:   "binding <ADDRESS> <EXCHANGE NAME> <TARGET NAME>",
-		Short: "Get the binding or bindings between two resources",
-		Args:  cobra.ExactArgs(3),
+// createPolicyCommand creates the `buneary create policy` command, making sure that
+// exactly four arguments are passed.
+func createPolicyCommand(options *globalOptions) *cobra.Command {
+	createPolicyOptions := &createPolicyOptions{
+		globalOptions: options,
+	}
+
+	createPolicy := &cobra.Command{
+		Use:   "policy <ADDRESS> <NAME> <PATTERN> <DEFINITION>",
+		Short: "Create a new policy",
+		Args:  cobra.ExactArgs(4),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGetBindings(options, args)
+			return runCreatePolicy(createPolicyOptions, args)
 		},
 	}
 
-	return getQueue
+	createPolicy.Flags().
+		StringVar(&createPolicyOptions.applyTo, "apply-to", "", "apply the policy to queues, exchanges or all")
+	createPolicy.Flags().
+		IntVar(&createPolicyOptions.priority, "priority", 0, "the priority of the policy")
+	createPolicy.Flags().
+		BoolVar(&createPolicyOptions.operator, "operator", false, "create an operator policy")
+
+	return createPolicy
 }
 
-// runGetBindings either returns all bindings  or - if a queue name has been specified as second
-// argument - a single binding. In case the password or both the user and password aren't
-// provided, it will go into interactive mode.
+// runCreatePolicy creates a new policy by reading the command line data, setting the
+// configuration and calling the CreatePolicy function. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
 //
-// This flexibility allows runGetBindings to be used by both `buneary get bindings` as well as
-// `buneary get binding`.
-func runGetBindings(options *globalOptions, args []string) error {
+// The policy definition is expected to be a JSON object. Unless --apply-to is used,
+// policies apply to all resources, and operator policies apply to queues.
+func runCreatePolicy(options *createPolicyOptions, args []string) error {
 	var (
-		address = args[0]
+		address    = args[0]
+		name       = args[1]
+		pattern    = args[2]
+		definition = args[3]
 	)
 
-	user, password := getOrReadInCredentials(options)
-
-	provider := NewProvider(&RabbitMQConfig{
-		Address:  address,
-		User:     user,
-		Password: password,
-	})
+	policy := Policy{
+		Name:     name,
+		Pattern:  pattern,
+		ApplyTo:  options.applyTo,
+		Priority: options.priority,
+		Operator: options.operator,
+	}
 
-	// The default filter will let pass all bindings regardless of their names.
-	filter := func(_ Binding) bool {
-		return true
+	if err := json.Unmarshal([]byte(definition), &policy.Definition); err != nil {
+		return fmt.Errorf("parsing policy definition: %w", err)
 	}
 
-	// However, if a source exchange and a binding target have been specified as
-	// second argument, only that particular binding should be returned.
-	if len(args) > 2 {
-		filter = func(binding Binding) bool {
-			return binding.From.Name == args[1] &&
-				binding.TargetName == args[2]
+	if policy.ApplyTo == "" {
+		policy.ApplyTo = "all"
+		if policy.Operator {
+			policy.ApplyTo = "queues"
 		}
 	}
 
-	bindings, err := provider.GetBindings(filter)
-	if err != nil {
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	if err := provider.CreatePolicy(policy); err != nil {
 		return err
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"From", "Target", "Type", "Binding Key"})
+	options.printSuccess("policy created successfully")
 
-	for _, binding := range bindings {
-		row := make([]string, 4)
-		row[0] = binding.From.Name
+	return nil
+}
+
+// getCommand creates the `buneary get` command without any functionality.
+func getCommand(options *globalOptions) *cobra.Command {
+	get := &cobra.Command{
+		Use:   "get <COMMAND>",
+		Short: "Get a resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	get.AddCommand(getExchangesCommand(options))
+	get.AddCommand(getExchangeCommand(options))
+	get.AddCommand(getQueuesCommand(options))
+	get.AddCommand(getQueueCommand(options))
+	get.AddCommand(getBindingsCommand(options))
+	get.AddCommand(getBindingCommand(options))
+	get.AddCommand(getMessagesCommand(options))
+	get.AddCommand(getUsersCommand(options))
+	get.AddCommand(getPermissionsCommand(options))
+	get.AddCommand(getVhostsCommand(options))
+	get.AddCommand(getPoliciesCommand(options))
+	get.AddCommand(getConnectionsCommand(options))
+	get.AddCommand(getChannelsCommand(options))
+	get.AddCommand(getConsumersCommand(options))
+
+	return get
+}
+
+// listOptions defines options for filtering, sorting and limiting listed resources.
+type listOptions struct {
+	filters []string
+	sortBy  string
+	limit   int
+}
+
+// addListFlags registers the --filter, --sort-by and --limit flags for the given command.
+func addListFlags(cmd *cobra.Command, options *listOptions) {
+	cmd.Flags().
+		StringArrayVar(&options.filters, "filter", nil, "only get resources matching this expression, e.g. messages>1000")
+	cmd.Flags().
+		StringVar(&options.sortBy, "sort-by", "", "sort by this field, optionally suffixed with :asc or :desc")
+	cmd.Flags().
+		IntVar(&options.limit, "limit", 0, "get at most this many resources")
+}
+
+// errLimitReached is returned by page handlers to stop reading further pages as soon as
+// the amount of resources specified with --limit has been printed.
+var errLimitReached = errors.New("limit reached")
+
+// pageLimiter keeps track of the amount of resources printed when streaming pages.
+type pageLimiter struct {
+	limit int
+	count int
+}
+
+// take returns how many resources of a page with the given size may be printed without
+// exceeding the limit. A limit of zero or less means no limit.
+func (l *pageLimiter) take(size int) int {
+	if l.limit > 0 && l.count+size > l.limit {
+		size = l.limit - l.count
+	}
+	l.count += size
+	return size
+}
+
+// err returns errLimitReached if the limit has been reached.
+func (l *pageLimiter) err() error {
+	if l.limit > 0 && l.count >= l.limit {
+		return errLimitReached
+	}
+	return nil
+}
+
+// pagedTable prints a table page by page, so that each page is printed as soon as it
+// has been received. The header is only printed along with the first page. The column
+// widths of the previous pages are kept as minimum widths, so that the rows of all pages
+// line up unless a later page contains a wider value.
+type pagedTable struct {
+	header  []string
+	widths  []int
+	started bool
+}
+
+// newPagedTable creates a new pagedTable with the given column titles.
+func newPagedTable(header []string) *pagedTable {
+	widths := make([]int, len(header))
+
+	for i, title := range header {
+		widths[i] = tablewriter.DisplayWidth(tablewriter.Title(title))
+	}
+
+	return &pagedTable{
+		header: header,
+		widths: widths,
+	}
+}
+
+// print prints the given rows without the bottom border. Cells aren't wrapped, so that
+// the column widths are known before printing.
+func (p *pagedTable) print(rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+
+	for _, row := range rows {
+		for i, cell := range row {
+			if width := tablewriter.DisplayWidth(cell); width > p.widths[i] {
+				p.widths[i] = width
+			}
+		}
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetBorders(tablewriter.Border{Left: true, Right: true, Top: !p.started})
+
+	if !p.started {
+		table.SetHeader(p.header)
+	}
+
+	for i, width := range p.widths {
+		table.SetColMinWidth(i, width)
+	}
+
+	table.AppendBulk(rows)
+	table.Render()
+
+	p.started = true
+}
+
+// close prints the bottom border. If no rows have been printed, an empty table is
+// printed instead.
+func (p *pagedTable) close() {
+	if !p.started {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader(p.header)
+		table.Render()
+		return
+	}
+
+	line := "+"
+
+	for _, width := range p.widths {
+		line += strings.Repeat("-", width+2) + "+"
+	}
+
+	fmt.Fprintln(os.Stdout, line)
+}
+
+// listQuery builds a ListQuery from the given list options, so that the resources are
+// filtered and sorted by the server where possible. If a resource name is given, only
+// that resource is requested. Otherwise, the first `name=~` or `name=` filter is used.
+//
+// The returned bool indicates whether the resources can be streamed, which is the case
+// if no sorting is required or if the server supports sorting by the given field.
+func listQuery(options listOptions, names []string, sortKeys map[string]string) (ListQuery, bool, error) {
+	var query ListQuery
+
+	if len(names) > 0 {
+		query.Name = "^" + regexp.QuoteMeta(names[0]) + "$"
+		query.Regex = true
+	}
+
+	for _, filter := range options.filters {
+		if query.Name != "" {
+			break
+		}
+
+		expression, err := ParseFilterExpression(filter)
+		if err != nil {
+			return ListQuery{}, false, err
+		}
+
+		if normalizeFieldName(expression.Field) != "name" {
+			continue
+		}
+
+		switch expression.Operator {
+		case "=~":
+			query.Name, query.Regex = expression.Value, true
+		case "=":
+			query.Name, query.Regex = "^"+regexp.QuoteMeta(expression.Value)+"$", true
+		}
+	}
+
+	if options.sortBy == "" {
+		return query, true, nil
+	}
+
+	field, descending, err := parseSortBy(options.sortBy)
+	if err != nil {
+		return ListQuery{}, false, err
+	}
+
+	if _, ok := sortKeys[normalizeFieldName(field)]; !ok {
+		return query, false, nil
+	}
+
+	query.SortBy, query.Descending = field, descending
+
+	return query, true, nil
+}
+
+// getExchangesOptions defines options for getting exchanges.
+type getExchangesOptions struct {
+	*globalOptions
+	listOptions
+}
+
+// getExchangesCommand creates the `buneary get exchanges` command, making sure that
+// exactly one argument is passed.
+func getExchangesCommand(options *globalOptions) *cobra.Command {
+	getExchangesOptions := &getExchangesOptions{
+		globalOptions: options,
+	}
+
+	getExchanges := &cobra.Command{
+		Use:   "exchanges <ADDRESS>",
+		Short: "Get all available exchanges",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetExchanges(getExchangesOptions, args)
+		},
+	}
+
+	addListFlags(getExchanges, &getExchangesOptions.listOptions)
+
+	return getExchanges
+}
+
+// getExchangeCommand creates the `buneary get exchange` command, making sure that exactly
+// two arguments are passed.
+func getExchangeCommand(options *globalOptions) *cobra.Command {
+	getExchangesOptions := &getExchangesOptions{
+		globalOptions: options,
+	}
+
+	getExchange := &cobra.Command{
+		Use:   "exchange <ADDRESS> <NAME>",
+		Short: "Get a single exchange",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetExchanges(getExchangesOptions, args)
+		},
+	}
+
+	return getExchange
+}
+
+// runGetExchanges either returns all exchanges or - if an exchange name has been
+// specified as second argument - a single exchange. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+//
+// This flexibility allows runGetExchanges to be used by both `buneary get exchanges`
+// as well as `buneary get exchange`. The exchanges can be filtered, sorted and limited
+// using the --filter, --sort-by and --limit flags.
+func runGetExchanges(options *getExchangesOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	match, err := CompileFilters(options.filters, Exchange{})
+	if err != nil {
+		return err
+	}
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	// The default filter will let pass all exchanges regardless of their names.
+	filter := func(exchange Exchange) bool {
+		return match(exchange)
+	}
+
+	// However, if an exchange name has been specified as second argument, only
+	// that particular exchange should be returned.
+	if len(args) > 1 {
+		filter = func(exchange Exchange) bool {
+			return exchange.Name == args[1] && match(exchange)
+		}
+	}
+
+	query, streamable, err := listQuery(options.listOptions, args[1:], exchangeSortKeys)
+	if err != nil {
+		return err
+	}
+
+	// If the server is able to sort the exchanges, they are read page by page and
+	// each page is printed as soon as it has been received. Otherwise, all exchanges
+	// have to be read and sorted first.
+	if streamable {
+		table := newPagedTable(exchangesHeader)
+		limiter := pageLimiter{limit: options.limit}
+
+		err := provider.StreamExchanges(query, filter, func(exchanges []Exchange) error {
+			table.print(exchangeRows(exchanges[:limiter.take(len(exchanges))]))
+			return limiter.err()
+		})
+		if err != nil && !errors.Is(err, errLimitReached) {
+			return err
+		}
+
+		table.close()
+
+		return nil
+	}
+
+	exchanges, err := provider.GetExchanges(filter)
+	if err != nil {
+		return err
+	}
+
+	if err := SortResources(exchanges, options.sortBy); err != nil {
+		return err
+	}
+
+	LimitResources(&exchanges, options.limit)
+
+	renderExchanges(exchanges)
+
+	return nil
+}
+
+// exchangesHeader holds the column titles of exchange tables.
+var exchangesHeader = []string{"Name", "Type", "Durable", "Auto-Delete", "Internal"}
+
+// renderExchanges renders the given exchanges as table.
+func renderExchanges(exchanges []Exchange) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(exchangesHeader)
+	table.AppendBulk(exchangeRows(exchanges))
+	table.Render()
+}
+
+// exchangeRows converts the given exchanges into table rows.
+func exchangeRows(exchanges []Exchange) [][]string {
+	rows := make([][]string, 0, len(exchanges))
+
+	for _, exchange := range exchanges {
+		row := make([]string, 5)
+		row[0] = exchange.Name
+		row[1] = string(exchange.Type)
+		row[2] = boolToString(exchange.Durable)
+		row[3] = boolToString(exchange.AutoDelete)
+		row[4] = boolToString(exchange.Internal)
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// getQueuesOptions defines options for getting queues.
+type getQueuesOptions struct {
+	*globalOptions
+	listOptions
+	watch    bool
+	interval time.Duration
+}
+
+// getQueuesCommand creates the `buneary get queues` command, making sure that
+// exactly one argument is passed.
+func getQueuesCommand(options *globalOptions) *cobra.Command {
+	getQueuesOptions := &getQueuesOptions{
+		globalOptions: options,
+	}
+
+	getQueues := &cobra.Command{
+		Use:   "queues <ADDRESS>",
+		Short: "Get all available queues",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetQueues(getQueuesOptions, args)
+		},
+	}
+
+	getQueues.Flags().
+		BoolVarP(&getQueuesOptions.watch, "watch", "w", false, "continuously refresh the queues")
+	getQueues.Flags().
+		DurationVar(&getQueuesOptions.interval, "interval", 2*time.Second, "the refresh interval in watch mode")
+
+	addListFlags(getQueues, &getQueuesOptions.listOptions)
+
+	return getQueues
+}
+
+// getQueueCommand creates the `buneary get queue` command, making sure that exactly two
+// arguments are passed.
+func getQueueCommand(options *globalOptions) *cobra.Command {
+	getQueuesOptions := &getQueuesOptions{
+		globalOptions: options,
+	}
+
+	getQueue := &cobra.Command{
+		Use:   "queue <ADDRESS> <NAME>",
+		Short: "Get a single queue",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetQueues(getQueuesOptions, args)
+		},
+	}
+
+	getQueue.Flags().
+		BoolVarP(&getQueuesOptions.watch, "watch", "w", false, "continuously refresh the queue")
+	getQueue.Flags().
+		DurationVar(&getQueuesOptions.interval, "interval", 2*time.Second, "the refresh interval in watch mode")
+
+	return getQueue
+}
+
+// runGetQueues either returns all queues or - if a queue name has been specified as second
+// argument - a single queue. In case the password or both the user and password aren't
+// provided, it will go into interactive mode.
+//
+// This flexibility allows runGetQueues to be used by both `buneary get queues` as well as
+// `buneary get queue`. Using the --watch flag, the queues are refreshed continuously.
+// The queues can be filtered, sorted and limited using the --filter, --sort-by and
+// --limit flags.
+func runGetQueues(options *getQueuesOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	if options.watch && options.interval <= 0 {
+		return errors.New("--interval has to be positive")
+	}
+
+	match, err := CompileFilters(options.filters, Queue{})
+	if err != nil {
+		return err
+	}
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	// The default filter will let pass all queues regardless of their names.
+	filter := func(queue Queue) bool {
+		return match(queue)
+	}
+
+	// However, if a queue name has been specified as second argument, only that
+	// particular queue should be returned.
+	if len(args) > 1 {
+		filter = func(queue Queue) bool {
+			return queue.Name == args[1] && match(queue)
+		}
+	}
+
+	if options.watch {
+		return watchQueues(options, provider, filter)
+	}
+
+	query, streamable, err := listQuery(options.listOptions, args[1:], queueSortKeys)
+	if err != nil {
+		return err
+	}
+
+	// If the server is able to sort the queues, they are read page by page and each
+	// page is printed as soon as it has been received. Otherwise, all queues have to
+	// be read and sorted first.
+	if streamable {
+		table := newPagedTable(queuesHeader)
+		limiter := pageLimiter{limit: options.limit}
+
+		err := provider.StreamQueues(query, filter, func(queues []Queue) error {
+			table.print(queueRows(queues[:limiter.take(len(queues))]))
+			return limiter.err()
+		})
+		if err != nil && !errors.Is(err, errLimitReached) {
+			return err
+		}
+
+		table.close()
+
+		return nil
+	}
+
+	queues, err := getQueues(provider, filter, options.listOptions)
+	if err != nil {
+		return err
+	}
+
+	renderQueues(queues)
+
+	return nil
+}
+
+// queuesHeader holds the column titles of queue tables.
+var queuesHeader = []string{"Name", "Durable", "Auto-Delete", "Leader", "Messages", "MessagesUnAck", "Consumers", "Memory", "Effective Policy"}
+
+// renderQueues renders the given queues as table.
+func renderQueues(queues []Queue) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(queuesHeader)
+	table.AppendBulk(queueRows(queues))
+	table.Render()
+}
+
+// queueRows converts the given queues into table rows.
+func queueRows(queues []Queue) [][]string {
+	rows := make([][]string, 0, len(queues))
+
+	for _, queue := range queues {
+		row := make([]string, 9)
+		row[0] = queue.Name
+		row[1] = boolToString(queue.Durable)
+		row[2] = boolToString(queue.AutoDelete)
+		row[3] = queue.Node
+		row[4] = strconv.Itoa(queue.Messages)
+		row[5] = strconv.Itoa(queue.MessagesUnAck)
+		row[6] = strconv.Itoa(queue.Consumers)
+		row[7] = strconv.FormatInt(queue.Memory, 10)
+		row[8] = queue.Policy
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// watchQueues reads the queues passing the filter in the configured interval and redraws
+// them in place until the command gets interrupted. Along with the message counts, the
+// change since the previous refresh, the message rates and the estimated time until
+// the queue is drained are displayed.
+func watchQueues(options *getQueuesOptions, provider Provider, filter func(queue Queue) bool) error {
+	var (
+		previous = make(map[string]int)
+		ticker   = time.NewTicker(options.interval)
+		signalCh = make(chan os.Signal, 1)
+	)
+
+	defer ticker.Stop()
+	signal.Notify(signalCh, os.Interrupt)
+	defer signal.Stop(signalCh)
+
+	for {
+		queues, err := getQueues(provider, filter, options.listOptions)
+		if err != nil {
+			return err
+		}
+
+		// Move the cursor to the top left corner and clear the screen.
+		_, _ = options.out.WriteString("\033[H\033[2J")
+		_, _ = options.out.WriteString(fmt.Sprintf("Every %s: %s\n\n", options.interval, time.Now().Format(time.RFC1123)))
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Name", "Messages", "Change", "MessagesUnAck", "In/s", "Out/s", "Time to Drain"})
+
+		for _, queue := range queues {
+			change := ""
+			if count, ok := previous[queue.Name]; ok {
+				change = fmt.Sprintf("%+d", queue.Messages-count)
+			}
+			previous[queue.Name] = queue.Messages
+
+			row := make([]string, 7)
+			row[0] = queue.Name
+			row[1] = strconv.Itoa(queue.Messages)
+			row[2] = change
+			row[3] = strconv.Itoa(queue.MessagesUnAck)
+			row[4] = fmt.Sprintf("%.1f", queue.PublishRate)
+			row[5] = fmt.Sprintf("%.1f", queue.DeliverRate)
+			row[6] = timeToDrain(queue)
+			table.Append(row)
+		}
+
+		table.Render()
+
+		select {
+		case <-ticker.C:
+		case <-signalCh:
+			return nil
+		}
+	}
+}
+
+// getQueues returns the queues passing the filter, sorted and limited according to the
+// given list options.
+func getQueues(provider Provider, filter func(queue Queue) bool, options listOptions) ([]Queue, error) {
+	queues, err := provider.GetQueues(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := SortResources(queues, options.sortBy); err != nil {
+		return nil, err
+	}
+
+	LimitResources(&queues, options.limit)
+
+	return queues, nil
+}
+
+// timeToDrain estimates the time until the given queue is empty, based on the current
+// message rates. If the queue isn't being drained, "never" is returned.
+func timeToDrain(queue Queue) string {
+	if queue.Messages == 0 {
+		return "0s"
+	}
+
+	rate := queue.DeliverRate - queue.PublishRate
+	if rate <= 0 {
+		return "never"
+	}
+
+	seconds := float64(queue.Messages) / rate
+
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// getBindingsOptions defines options for getting bindings.
+type getBindingsOptions struct {
+	*globalOptions
+	listOptions
+	arguments []string
+}
+
+// getBindingsCommand creates the `buneary get bindings` command, making sure that
+// exactly one argument is passed.
+func getBindingsCommand(options *globalOptions) *cobra.Command {
+	getBindingsOptions := &getBindingsOptions{
+		globalOptions: options,
+	}
+
+	getQueues := &cobra.Command{
+		Use:   "bindings <ADDRESS>",
+		Short: "Get all available bindings",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetBindings(getBindingsOptions, args)
+		},
+	}
+
+	getQueues.Flags().
+		StringArrayVar(&getBindingsOptions.arguments, "arg", nil, "only get bindings with this argument in the form key=value")
+
+	addListFlags(getQueues, &getBindingsOptions.listOptions)
+
+	return getQueues
+}
+
+// getBindingCommand creates the `buneary get binding` command, making sure that exactly
+// three arguments are passed.
+func getBindingCommand(options *globalOptions) *cobra.Command {
+	getBindingsOptions := &getBindingsOptions{
+		globalOptions: options,
+	}
+
+	getQueue := &cobra.Command{
+		Use:   "binding <ADDRESS> <EXCHANGE NAME> <TARGET NAME>",
+		Short: "Get the binding or bindings between two resources",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetBindings(getBindingsOptions, args)
+		},
+	}
+
+	getQueue.Flags().
+		StringArrayVar(&getBindingsOptions.arguments, "arg", nil, "only get bindings with this argument in the form key=value")
+
+	return getQueue
+}
+
+// runGetBindings either returns all bindings  or - if a queue name has been specified as second
+// argument - a single binding. In case the password or both the user and password aren't
+// provided, it will go into interactive mode.
+//
+// This flexibility allows runGetBindings to be used by both `buneary get bindings` as well as
+// `buneary get binding`. Using the --arg flag, bindings can be filtered by their arguments.
+// The bindings can be filtered, sorted and limited using the --filter, --sort-by and
+// --limit flags.
+func runGetBindings(options *getBindingsOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	arguments, err := parseArguments(options.arguments)
+	if err != nil {
+		return err
+	}
+
+	match, err := CompileFilters(options.filters, Binding{})
+	if err != nil {
+		return err
+	}
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	// The default filter will let pass all bindings regardless of their names.
+	filter := func(binding Binding) bool {
+		return hasArguments(binding.Arguments, arguments) && match(binding)
+	}
+
+	// However, if a source exchange and a binding target have been specified as
+	// second argument, only that particular binding should be returned.
+	if len(args) > 2 {
+		filter = func(binding Binding) bool {
+			return binding.From.Name == args[1] &&
+				binding.TargetName == args[2] &&
+				hasArguments(binding.Arguments, arguments) &&
+				match(binding)
+		}
+	}
+
+	bindings, err := provider.GetBindings(filter)
+	if err != nil {
+		return err
+	}
+
+	if err := SortResources(bindings, options.sortBy); err != nil {
+		return err
+	}
+
+	LimitResources(&bindings, options.limit)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"From", "Target", "Type", "Binding Key", "Arguments"})
+
+	for _, binding := range bindings {
+		row := make([]string, 5)
+		row[0] = binding.From.Name
 		row[1] = binding.TargetName
 		row[2] = string(binding.Type)
 		row[3] = binding.Key
+		row[4] = argumentsToString(binding.Arguments)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getMessagesOptions defines options for reading messages.
+type getMessagesOptions struct {
+	*globalOptions
+	max     int
+	requeue bool
+	force   bool
+	offset  string
+}
+
+// getMessagesCommand creates the `buneary get messages` command, making sure that exactly
+// two arguments are passed.
+func getMessagesCommand(options *globalOptions) *cobra.Command {
+	getMessagesOptions := &getMessagesOptions{
+		globalOptions: options,
+	}
+
+	getMessages := &cobra.Command{
+		Use:   "messages <ADDRESS> <QUEUE NAME>",
+		Short: "Get messages in a queue",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetMessages(getMessagesOptions, args)
+		},
+	}
+
+	getMessages.Flags().
+		IntVar(&getMessagesOptions.max, "max", 1, "maximum messages to read")
+	getMessages.Flags().
+		BoolVar(&getMessagesOptions.requeue, "requeue", false, "re-queue the messages after reading them")
+	getMessages.Flags().
+		BoolVarP(&getMessagesOptions.force, "force", "f", false, "force running this command without opt-in")
+	getMessages.Flags().
+		StringVar(&getMessagesOptions.offset, "offset", "", "read from a stream starting at this offset")
+
+	return getMessages
+}
+
+// runGetMessages gets messages by reading the command line data, setting the
+// configuration and calling the GetMessages function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// If the --offset flag is used, the queue is read as stream. Since this won't remove
+// any messages from the stream, there's no need to ask the user for confirmation.
+func runGetMessages(options *getMessagesOptions, args []string) error {
+	var (
+		address = args[0]
+		queue   = args[1]
+	)
+
+	var offset interface{}
+
+	if options.offset != "" {
+		parsed, err := ParseStreamOffset(options.offset)
+		if err != nil {
+			return err
+		}
+		offset = parsed
+	}
+
+	message := "Reading the messages from the queue will de-queue them." +
+		"To re-queue them, pass the --requeue flag. Do you want to continue?"
+
+	if !options.force && offset == nil {
+		ok := confirm(options.globalOptions, message)
+		if !ok {
+			return nil
+		}
+	}
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	var (
+		messages []Message
+		err      error
+	)
+
+	if offset != nil {
+		messages, err = provider.GetStreamMessages(Queue{Name: queue}, offset, options.max)
+	} else {
+		messages, err = provider.GetMessages(Queue{Name: queue}, options.max, options.requeue)
+	}
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Exchange", "Routing Key", "Body"})
+
+	for _, message := range messages {
+		row := make([]string, 3)
+		row[0] = message.Target.Name
+		row[1] = message.RoutingKey
+		row[2] = string(message.Body)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getUsersCommand creates the `buneary get users` command, making sure that exactly
+// one argument is passed.
+func getUsersCommand(options *globalOptions) *cobra.Command {
+	getUsers := &cobra.Command{
+		Use:   "users <ADDRESS>",
+		Short: "Get all available users",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetUsers(options, args)
+		},
+	}
+
+	return getUsers
+}
+
+// runGetUsers returns all users. In case the password or both the user and password
+// aren't provided, it will go into interactive mode.
+func runGetUsers(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	users, err := provider.GetUsers(func(_ User) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Tags", "Hashing Algorithm"})
+
+	for _, user := range users {
+		row := make([]string, 3)
+		row[0] = user.Name
+		row[1] = strings.Join(user.Tags, ", ")
+		row[2] = user.HashingAlgorithm
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getPermissionsCommand creates the `buneary get permissions` command, making sure
+// that one or two arguments are passed.
+func getPermissionsCommand(options *globalOptions) *cobra.Command {
+	getPermissions := &cobra.Command{
+		Use:   "permissions <ADDRESS> [USER]",
+		Short: "Get the permissions of all users or a single user",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetPermissions(options, args)
+		},
+	}
+
+	return getPermissions
+}
+
+// runGetPermissions either returns the permissions of all users or - if a user name has
+// been specified as second argument - the permissions of a single user. In case the
+// password or both the user and password aren't provided, it will go into interactive
+// mode. If the --vhost flag is used, only permissions for that virtual host are returned.
+func runGetPermissions(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	filter := func(permissions Permissions) bool {
+		if len(args) > 1 && permissions.User != args[1] {
+			return false
+		}
+		return options.vhost == "" || permissions.Vhost == options.vhost
+	}
+
+	permissions, err := provider.GetPermissions(filter)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"User", "Vhost", "Configure", "Write", "Read"})
+
+	for _, p := range permissions {
+		row := make([]string, 5)
+		row[0] = p.User
+		row[1] = p.Vhost
+		row[2] = p.Configure
+		row[3] = p.Write
+		row[4] = p.Read
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getVhostsCommand creates the `buneary get vhosts` command, making sure that exactly
+// one argument is passed.
+func getVhostsCommand(options *globalOptions) *cobra.Command {
+	getVhosts := &cobra.Command{
+		Use:   "vhosts <ADDRESS>",
+		Short: "Get all available virtual hosts",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetVhosts(options, args)
+		},
+	}
+
+	return getVhosts
+}
+
+// runGetVhosts returns all virtual hosts along with their message totals. In case the
+// password or both the user and password aren't provided, it will go into interactive
+// mode.
+func runGetVhosts(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	vhosts, err := provider.GetVhosts(func(_ Vhost) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Description", "Tags", "Default Queue Type", "Messages", "MessagesReady", "MessagesUnAck"})
+
+	for _, vhost := range vhosts {
+		row := make([]string, 7)
+		row[0] = vhost.Name
+		row[1] = vhost.Description
+		row[2] = strings.Join(vhost.Tags, ", ")
+		row[3] = string(vhost.DefaultQueueType)
+		row[4] = strconv.Itoa(vhost.Messages)
+		row[5] = strconv.Itoa(vhost.MessagesReady)
+		row[6] = strconv.Itoa(vhost.MessagesUnAck)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getPoliciesCommand creates the `buneary get policies` command, making sure that
+// exactly one argument is passed.
+func getPoliciesCommand(options *globalOptions) *cobra.Command {
+	getPolicies := &cobra.Command{
+		Use:   "policies <ADDRESS>",
+		Short: "Get all policies and operator policies",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetPolicies(options, args)
+		},
+	}
+
+	return getPolicies
+}
+
+// runGetPolicies returns all policies and operator policies. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+func runGetPolicies(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	policies, err := provider.GetPolicies(func(_ Policy) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Vhost", "Pattern", "Apply To", "Priority", "Definition", "Operator"})
+
+	for _, policy := range policies {
+		row := make([]string, 7)
+		row[0] = policy.Name
+		row[1] = policy.Vhost
+		row[2] = policy.Pattern
+		row[3] = policy.ApplyTo
+		row[4] = strconv.Itoa(policy.Priority)
+		row[5] = argumentsToString(policy.Definition)
+		row[6] = boolToString(policy.Operator)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getConnectionsCommand creates the `buneary get connections` command, making sure
+// that exactly one argument is passed.
+func getConnectionsCommand(options *globalOptions) *cobra.Command {
+	getConnections := &cobra.Command{
+		Use:   "connections <ADDRESS>",
+		Short: "Get all client connections",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetConnections(options, args)
+		},
+	}
+
+	return getConnections
+}
+
+// runGetConnections returns all client connections. In case the password or both the
+// user and password aren't provided, it will go into interactive mode.
+func runGetConnections(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	connections, err := provider.GetConnections(func(_ Connection) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "User", "Vhost", "Peer Host", "Client", "State", "Channels"})
+
+	for _, connection := range connections {
+		row := make([]string, 7)
+		row[0] = connection.Name
+		row[1] = connection.User
+		row[2] = connection.Vhost
+		row[3] = fmt.Sprintf("%s:%d", connection.PeerHost, connection.PeerPort)
+		row[4] = clientPropertiesToString(connection.ClientProperties)
+		row[5] = connection.State
+		row[6] = strconv.Itoa(connection.Channels)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getChannelsCommand creates the `buneary get channels` command, making sure that
+// exactly one argument is passed.
+func getChannelsCommand(options *globalOptions) *cobra.Command {
+	getChannels := &cobra.Command{
+		Use:   "channels <ADDRESS>",
+		Short: "Get all channels",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetChannels(options, args)
+		},
+	}
+
+	return getChannels
+}
+
+// runGetChannels returns all channels of all client connections. In case the password
+// or both the user and password aren't provided, it will go into interactive mode.
+func runGetChannels(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	channels, err := provider.GetChannels(func(_ Channel) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Connection", "User", "Vhost", "Prefetch", "Consumers", "MessagesUnAck"})
+
+	for _, channel := range channels {
+		row := make([]string, 7)
+		row[0] = channel.Name
+		row[1] = channel.Connection
+		row[2] = channel.User
+		row[3] = channel.Vhost
+		row[4] = strconv.Itoa(channel.Prefetch)
+		row[5] = strconv.Itoa(channel.Consumers)
+		row[6] = strconv.Itoa(channel.MessagesUnAck)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// getConsumersCommand creates the `buneary get consumers` command, making sure that
+// one or two arguments are passed.
+func getConsumersCommand(options *globalOptions) *cobra.Command {
+	getConsumers := &cobra.Command{
+		Use:   "consumers <ADDRESS> [QUEUE NAME]",
+		Short: "Get all consumers or the consumers of a queue",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGetConsumers(options, args)
+		},
+	}
+
+	return getConsumers
+}
+
+// runGetConsumers either returns all consumers or - if a queue name has been specified
+// as second argument - the consumers of a single queue. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+func runGetConsumers(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	// The default filter will let pass all consumers regardless of their queue.
+	filter := func(_ Consumer) bool {
+		return true
+	}
+
+	// However, if a queue name has been specified as second argument, only the
+	// consumers of that particular queue should be returned.
+	if len(args) > 1 {
+		filter = func(consumer Consumer) bool {
+			return consumer.Queue == args[1]
+		}
+	}
+
+	consumers, err := provider.GetConsumers(filter)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Queue", "Consumer Tag", "Channel", "Connection", "Ack Mode", "Prefetch", "Exclusive", "Single Active"})
+
+	for _, consumer := range consumers {
+		ackMode := "auto"
+		if consumer.AckRequired {
+			ackMode = "manual"
+		}
+
+		row := make([]string, 8)
+		row[0] = consumer.Queue
+		row[1] = consumer.Tag
+		row[2] = consumer.Channel
+		row[3] = consumer.Connection
+		row[4] = ackMode
+		row[5] = strconv.Itoa(consumer.Prefetch)
+		row[6] = boolToString(consumer.Exclusive)
+		row[7] = boolToString(consumer.SingleActive)
+		table.Append(row)
+	}
+
+	table.Render()
+
+	return nil
+}
+
+// publishOptions defines options for publishing a message.
+type publishOptions struct {
+	*globalOptions
+	headers string
+}
+
+// publishCommand creates the `buneary publish` command, making sure that exactly
+// four command arguments are passed.
+func publishCommand(options *globalOptions) *cobra.Command {
+	publishOptions := &publishOptions{
+		globalOptions: options,
+	}
+
+	publish := &cobra.Command{
+		Use:   "publish <ADDRESS> <EXCHANGE> <ROUTING KEY> <BODY>",
+		Short: "Publish a message to an exchange",
+		Args:  cobra.ExactArgs(4),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPublish(publishOptions, args)
+		},
+	}
+
+	publish.Flags().
+		StringVar(&publishOptions.headers, "headers", "", "headers as comma-separated key-value pairs")
+
+	return publish
+}
+
+// runPublish publishes a message by reading the command line data, setting the
+// configuration and calling the PublishMessage function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+func runPublish(options *publishOptions, args []string) error {
+	var (
+		address    = args[0]
+		exchange   = args[1]
+		routingKey = args[2]
+		body       = args[3]
+	)
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	headers, err := parseHeaders(options.headers)
+	if err != nil {
+		return err
+	}
+
+	message := Message{
+		Target:     Exchange{Name: exchange},
+		Headers:    headers,
+		RoutingKey: routingKey,
+		Body:       []byte(body),
+	}
+
+	if err := provider.PublishMessage(message); err != nil {
+		return err
+	}
+
+	options.printSuccess("message published successfully")
+
+	return nil
+}
+
+// deleteCommand creates the `buneary delete` command without any functionality.
+func deleteCommand(options *globalOptions) *cobra.Command {
+	delete := &cobra.Command{
+		Use:   "delete <COMMAND>",
+		Short: "Delete a resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	delete.AddCommand(deleteExchangeCommand(options))
+	delete.AddCommand(deleteQueueCommand(options))
+	delete.AddCommand(deleteUserCommand(options))
+	delete.AddCommand(deleteVhostCommand(options))
+	delete.AddCommand(deletePolicyCommand(options))
+
+	return delete
+}
+
+// bulkDeleteOptions defines options for deleting all resources whose names match a
+// regular expression or prefix instead of deleting a single resource by name.
+type bulkDeleteOptions struct {
+	regex   string
+	prefix  string
+	force   bool
+	workers int
+}
+
+// addBulkDeleteFlags registers the flags of the given bulk deletion options on cmd.
+func addBulkDeleteFlags(cmd *cobra.Command, options *bulkDeleteOptions, kind string) {
+	cmd.Flags().
+		StringVar(&options.regex, "regex", "", "delete all "+kind+"s whose names match a regular expression")
+	cmd.Flags().
+		StringVar(&options.prefix, "prefix", "", "delete all "+kind+"s whose names start with a prefix")
+	cmd.Flags().
+		BoolVarP(&options.force, "force", "f", false, "force running this command without opt-in")
+	cmd.Flags().
+		IntVar(&options.workers, "workers", 4, "the number of "+kind+"s to delete concurrently")
+}
+
+// namePattern returns the regular expression matching the names of all resources to
+// delete. If neither --regex nor --prefix is set, nil is returned and exactly one
+// resource name is expected as second argument.
+func (o *bulkDeleteOptions) namePattern(args []string) (*regexp.Regexp, error) {
+	if o.regex != "" && o.prefix != "" {
+		return nil, errors.New("--regex and --prefix can't be used together")
+	}
+
+	if o.regex == "" && o.prefix == "" {
+		if len(args) != 2 {
+			return nil, errors.New("expected <ADDRESS> <NAME>, or <ADDRESS> with --regex or --prefix")
+		}
+		return nil, nil
+	}
+
+	if len(args) != 1 {
+		return nil, errors.New("a name can't be combined with --regex or --prefix")
+	}
+
+	if o.workers < 1 {
+		return nil, fmt.Errorf("invalid number of workers %d, expected at least 1", o.workers)
+	}
+
+	source := o.regex
+	if o.prefix != "" {
+		source = "^" + regexp.QuoteMeta(o.prefix)
+	}
+
+	pattern, err := regexp.Compile(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+
+	return pattern, nil
+}
+
+// deleteExchangeOptions defines options for deleting one or more exchanges.
+type deleteExchangeOptions struct {
+	*globalOptions
+	bulkDeleteOptions
+	conditions DeleteConditions
+}
+
+// deleteExchangeCommand creates the `buneary delete exchange` command, making sure
+// that either two arguments or one argument with --regex or --prefix are passed.
+func deleteExchangeCommand(options *globalOptions) *cobra.Command {
+	deleteExchangeOptions := &deleteExchangeOptions{
+		globalOptions: options,
+	}
+
+	deleteExchange := &cobra.Command{
+		Use:   "exchange <ADDRESS> [NAME]",
+		Short: "Delete an exchange",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteExchange(deleteExchangeOptions, args)
+		},
+	}
+
+	addBulkDeleteFlags(deleteExchange, &deleteExchangeOptions.bulkDeleteOptions, "exchange")
+
+	deleteExchange.Flags().
+		BoolVar(&deleteExchangeOptions.conditions.IfUnused, "if-unused", false, "only delete the exchange if it has no bindings")
+
+	return deleteExchange
+}
+
+// runDeleteExchange deletes an exchange by reading the command line data, setting the
+// configuration and calling the DeleteExchange function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// Unless the --force flag is used, the user has to confirm the deletion. If --regex
+// or --prefix is used, all matching exchanges are deleted instead. The default
+// exchange and the pre-defined `amq.*` exchanges are never matched.
+func runDeleteExchange(options *deleteExchangeOptions, args []string) error {
+	pattern, err := options.namePattern(args)
+	if err != nil {
+		return err
+	}
+
+	address := args[0]
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    targetVhost(options.globalOptions),
+	})
+
+	if pattern != nil {
+		exchanges, err := provider.GetExchanges(func(exchange Exchange) bool {
+			return !isSystemExchange(exchange) && pattern.MatchString(exchange.Name)
+		})
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, len(exchanges))
+		for i, exchange := range exchanges {
+			names[i] = exchange.Name
+		}
+
+		return runBulkDelete(options.globalOptions, &options.bulkDeleteOptions, "exchange", names, nil, func(name string) error {
+			return provider.DeleteExchange(Exchange{Name: name}, options.conditions)
+		})
+	}
+
+	exchange := Exchange{
+		Name: args[1],
+	}
+
+	message := fmt.Sprintf("Do you want to delete the exchange %s?", exchange.Name)
+
+	if !options.force {
+		ok := confirm(options.globalOptions, message)
+		if !ok {
+			return nil
+		}
+	}
+
+	if err := provider.DeleteExchange(exchange, options.conditions); err != nil {
+		return err
+	}
+
+	options.printSuccess("exchange deleted successfully")
+
+	return nil
+}
+
+// deleteQueueOptions defines options for deleting one or more queues.
+type deleteQueueOptions struct {
+	*globalOptions
+	bulkDeleteOptions
+	conditions DeleteConditions
+}
+
+// deleteQueueCommand creates the `buneary delete queue` command, making sure that
+// either two arguments or one argument with --regex or --prefix are passed.
+func deleteQueueCommand(options *globalOptions) *cobra.Command {
+	deleteQueueOptions := &deleteQueueOptions{
+		globalOptions: options,
+	}
+
+	deleteQueue := &cobra.Command{
+		Use:   "queue <ADDRESS> [NAME]",
+		Short: "Delete a queue",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteQueue(deleteQueueOptions, args)
+		},
+	}
+
+	addBulkDeleteFlags(deleteQueue, &deleteQueueOptions.bulkDeleteOptions, "queue")
+
+	deleteQueue.Flags().
+		BoolVar(&deleteQueueOptions.conditions.IfEmpty, "if-empty", false, "only delete the queue if it has no messages")
+	deleteQueue.Flags().
+		BoolVar(&deleteQueueOptions.conditions.IfUnused, "if-unused", false, "only delete the queue if it has no consumers")
+
+	return deleteQueue
+}
+
+// runDeleteQueue deletes a queue by reading the command line data, setting the
+// configuration and calling the DeleteQueue function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// Unless the --force flag is used, the user has to confirm the deletion, and will
+// be warned if the queue still holds messages. If --regex or --prefix is used, all
+// matching queues are deleted instead, and their message counts are listed. With
+// --if-empty or --if-unused, only queues satisfying these conditions are selected,
+// and the conditions are also passed to the server, so that queues which received
+// messages or consumers in the meantime won't be deleted.
+func runDeleteQueue(options *deleteQueueOptions, args []string) error {
+	pattern, err := options.namePattern(args)
+	if err != nil {
+		return err
+	}
+
+	address := args[0]
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    targetVhost(options.globalOptions),
+	})
+
+	if pattern != nil {
+		queues, err := provider.GetQueues(func(queue Queue) bool {
+			if options.conditions.IfEmpty && queue.Messages > 0 {
+				return false
+			}
+			if options.conditions.IfUnused && queue.Consumers > 0 {
+				return false
+			}
+			return pattern.MatchString(queue.Name)
+		})
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, len(queues))
+		details := make(map[string]string, len(queues))
+
+		for i, queue := range queues {
+			names[i] = queue.Name
+			details[queue.Name] = fmt.Sprintf("%d messages", queue.Messages)
+		}
+
+		return runBulkDelete(options.globalOptions, &options.bulkDeleteOptions, "queue", names, details, func(name string) error {
+			return provider.DeleteQueue(Queue{Name: name}, options.conditions)
+		})
+	}
+
+	queue := Queue{
+		Name: args[1],
+	}
+
+	if !options.force {
+		var queues []Queue
+
+		// The server only returns queues whose names contain the queue name, so that
+		// not all queues have to be read. The filter keeps the exact match.
+		err := provider.StreamQueues(ListQuery{Name: queue.Name}, func(q Queue) bool {
+			return q.Name == queue.Name
+		}, func(page []Queue) error {
+			queues = append(queues, page...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(queues) == 0 {
+			return fmt.Errorf("queue %s: %w", queue.Name, ErrNotFound)
+		}
+
+		queue = queues[0]
+
+		if queue.Messages > 0 {
+			warning := fmt.Sprintf("Warning: The queue %s still holds %d messages, which will be lost.\n",
+				queue.Name, queue.Messages)
+			_, _ = options.out.WriteString(warning)
+		}
+
+		message := fmt.Sprintf("Do you want to delete the queue %s with %d messages and %d consumers?",
+			queue.Name, queue.Messages, queue.Consumers)
+
+		ok := confirm(options.globalOptions, message)
+		if !ok {
+			return nil
+		}
+	}
+
+	if err := provider.DeleteQueue(queue, options.conditions); err != nil {
+		return err
+	}
+
+	options.printSuccess("queue deleted successfully")
+
+	return nil
+}
+
+// targetVhost returns the configured virtual host or the default virtual host `/`.
+// Setting the default virtual host explicitly ensures that listing resources prior
+// to deleting them only returns resources of the virtual host they're deleted from,
+// and that commands relating resources by their names don't mix virtual hosts.
+func targetVhost(options *globalOptions) string {
+	if options.vhost == "" {
+		return "/"
+	}
+	return options.vhost
+}
+
+// runBulkDelete lists the names of the given resources along with their details, if
+// any, and deletes them using the given function after the user has confirmed it,
+// unless --force is used. Up to --workers resources are deleted concurrently. Failed
+// deletions are reported, but don't stop the remaining resources from being deleted.
+func runBulkDelete(options *globalOptions, bulk *bulkDeleteOptions, kind string, names []string, details map[string]string, del func(name string) error) error {
+	if len(names) == 0 {
+		_, _ = options.out.WriteString(fmt.Sprintf("no %ss matched\n", kind))
+		return nil
+	}
+
+	_, _ = options.out.WriteString(fmt.Sprintf("The following %d %ss will be deleted:\n\n", len(names), kind))
+
+	for _, name := range names {
+		if detail, ok := details[name]; ok {
+			_, _ = options.out.WriteString(fmt.Sprintf("  %s (%s)\n", name, detail))
+			continue
+		}
+		_, _ = options.out.WriteString("  " + name + "\n")
+	}
+
+	_, _ = options.out.WriteString("\n")
+
+	if !bulk.force {
+		ok := confirm(options, "Do you want to continue?")
+		if !ok {
+			return nil
+		}
+	}
+
+	failed := 0
+
+	for _, result := range deleteConcurrently(names, bulk.workers, del) {
+		if result.err != nil {
+			_, _ = options.out.WriteString(fmt.Sprintf("deleting %s %s failed: %s\n", kind, result.name, result.err))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %ss could not be deleted", failed, len(names), kind)
+	}
+
+	options.printSuccess(fmt.Sprintf("%d %ss deleted successfully", len(names), kind))
+
+	return nil
+}
+
+// deleteResult is the result of deleting a single resource in a bulk deletion.
+type deleteResult struct {
+	name string
+	err  error
+}
+
+// deleteConcurrently calls del for all given names using a pool of workers, so that
+// at most the given number of deletions run at the same time. The results have the
+// same order as the names.
+func deleteConcurrently(names []string, workers int, del func(name string) error) []deleteResult {
+	var (
+		results = make([]deleteResult, len(names))
+		indexes = make(chan int)
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < workers && i < len(names); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for index := range indexes {
+				results[index] = deleteResult{
+					name: names[index],
+					err:  del(names[index]),
+				}
+			}
+		}()
+	}
+
+	for i := range names {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// deleteUserCommand creates the `buneary delete user` command, making sure that
+// exactly two arguments are passed.
+func deleteUserCommand(options *globalOptions) *cobra.Command {
+	deleteUser := &cobra.Command{
+		Use:   "user <ADDRESS> <NAME>",
+		Short: "Delete a user",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteUser(options, args)
+		},
+	}
+
+	return deleteUser
+}
+
+// runDeleteUser deletes a user by reading the command line data, setting the
+// configuration and calling the DeleteUser function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+func runDeleteUser(options *globalOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	if err := provider.DeleteUser(User{Name: name}); err != nil {
+		return err
+	}
+
+	options.printSuccess("user deleted successfully")
+
+	return nil
+}
+
+// deleteVhostOptions defines options for deleting a virtual host.
+type deleteVhostOptions struct {
+	*globalOptions
+	force bool
+}
+
+// deleteVhostCommand creates the `buneary delete vhost` command, making sure that
+// exactly two arguments are passed.
+func deleteVhostCommand(options *globalOptions) *cobra.Command {
+	deleteVhostOptions := &deleteVhostOptions{
+		globalOptions: options,
+	}
+
+	deleteVhost := &cobra.Command{
+		Use:   "vhost <ADDRESS> <NAME>",
+		Short: "Delete a virtual host",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeleteVhost(deleteVhostOptions, args)
+		},
+	}
+
+	deleteVhost.Flags().
+		BoolVarP(&deleteVhostOptions.force, "force", "f", false, "force running this command without opt-in")
+
+	return deleteVhost
+}
+
+// runDeleteVhost deletes a virtual host by reading the command line data, setting the
+// configuration and calling the DeleteVhost function. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+//
+// Since deleting a virtual host deletes all of its resources and messages, the user
+// has to confirm this unless the --force flag is used.
+func runDeleteVhost(options *deleteVhostOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	message := fmt.Sprintf("Deleting the vhost %s will delete all of its exchanges, queues "+
+		"and messages. Do you want to continue?", name)
+
+	if !options.force {
+		ok := confirm(options.globalOptions, message)
+		if !ok {
+			return nil
+		}
+	}
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	if err := provider.DeleteVhost(Vhost{Name: name}); err != nil {
+		return err
+	}
+
+	options.printSuccess("vhost deleted successfully")
+
+	return nil
+}
+
+// deletePolicyOptions defines options for deleting a policy.
+type deletePolicyOptions struct {
+	*globalOptions
+	operator bool
+}
+
+// deletePolicyCommand creates the `buneary delete policy` command, making sure that
+// exactly two arguments are passed.
+func deletePolicyCommand(options *globalOptions) *cobra.Command {
+	deletePolicyOptions := &deletePolicyOptions{
+		globalOptions: options,
+	}
+
+	deletePolicy := &cobra.Command{
+		Use:   "policy <ADDRESS> <NAME>",
+		Short: "Delete a policy",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeletePolicy(deletePolicyOptions, args)
+		},
+	}
+
+	deletePolicy.Flags().
+		BoolVar(&deletePolicyOptions.operator, "operator", false, "delete an operator policy")
+
+	return deletePolicy
+}
+
+// runDeletePolicy deletes a policy by reading the command line data, setting the
+// configuration and calling the DeletePolicy function. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+func runDeletePolicy(options *deletePolicyOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	if err := provider.DeletePolicy(Policy{Name: name, Operator: options.operator}); err != nil {
+		return err
+	}
+
+	options.printSuccess("policy deleted successfully")
+
+	return nil
+}
+
+// setCommand creates the `buneary set` command without any functionality.
+func setCommand(options *globalOptions) *cobra.Command {
+	set := &cobra.Command{
+		Use:   "set <COMMAND>",
+		Short: "Set a resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	set.AddCommand(setPermissionsCommand(options))
+
+	return set
+}
+
+// setPermissionsCommand creates the `buneary set permissions` command, making sure
+// that exactly five arguments are passed.
+func setPermissionsCommand(options *globalOptions) *cobra.Command {
+	setPermissions := &cobra.Command{
+		Use:   "permissions <ADDRESS> <USER> <CONFIGURE> <WRITE> <READ>",
+		Short: "Set the permissions of a user",
+		Args:  cobra.ExactArgs(5),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSetPermissions(options, args)
+		},
+	}
+
+	return setPermissions
+}
+
+// runSetPermissions sets the permissions of a user by reading the command line data,
+// setting the configuration and calling the SetPermissions function. In case the
+// password or both the user and password aren't provided, it will go into interactive
+// mode. The permissions apply to the virtual host specified with --vhost or `/`.
+func runSetPermissions(options *globalOptions, args []string) error {
+	var (
+		address   = args[0]
+		name      = args[1]
+		configure = args[2]
+		write     = args[3]
+		read      = args[4]
+	)
+
+	user, password := getOrReadInCredentials(options)
+
+	provider := newProvider(options, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	permissions := Permissions{
+		User:      name,
+		Configure: configure,
+		Write:     write,
+		Read:      read,
+	}
+
+	if err := provider.SetPermissions(permissions); err != nil {
+		return err
+	}
+
+	options.printSuccess("permissions set successfully")
+
+	return nil
+}
+
+// closeCommand creates the `buneary close` command without any functionality.
+func closeCommand(options *globalOptions) *cobra.Command {
+	close := &cobra.Command{
+		Use:   "close <COMMAND>",
+		Short: "Close a resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	close.AddCommand(closeConnectionCommand(options))
+
+	return close
+}
+
+// closeConnectionOptions defines options for closing a connection.
+type closeConnectionOptions struct {
+	*globalOptions
+	reason string
+}
+
+// closeConnectionCommand creates the `buneary close connection` command, making sure
+// that exactly two arguments are passed.
+func closeConnectionCommand(options *globalOptions) *cobra.Command {
+	closeConnectionOptions := &closeConnectionOptions{
+		globalOptions: options,
+	}
+
+	closeConnection := &cobra.Command{
+		Use:   "connection <ADDRESS> <NAME>",
+		Short: "Forcefully close a client connection",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCloseConnection(closeConnectionOptions, args)
+		},
+	}
+
+	closeConnection.Flags().
+		StringVar(&closeConnectionOptions.reason, "reason", "", "the reason sent to the client")
+
+	return closeConnection
+}
+
+// runCloseConnection closes a client connection by reading the command line data,
+// setting the configuration and calling the CloseConnection function. In case the
+// password or both the user and password aren't provided, it will go into interactive
+// mode.
+func runCloseConnection(options *closeConnectionOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	if err := provider.CloseConnection(Connection{Name: name}, options.reason); err != nil {
+		return err
+	}
+
+	options.printSuccess("connection closed successfully")
+
+	return nil
+}
+
+// purgeCommand creates the `buneary purge` command without any functionality.
+func purgeCommand(options *globalOptions) *cobra.Command {
+	purge := &cobra.Command{
+		Use:   "purge <COMMAND>",
+		Short: "Purge a resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	purge.AddCommand(purgeQueueCommand(options))
+
+	return purge
+}
+
+// purgeQueueOptions defines options for purging a queue.
+type purgeQueueOptions struct {
+	*globalOptions
+	force bool
+}
+
+// purgeQueueCommand creates the `buneary purge queue` command, making sure that
+// exactly two arguments are passed.
+func purgeQueueCommand(options *globalOptions) *cobra.Command {
+	purgeQueueOptions := &purgeQueueOptions{
+		globalOptions: options,
+	}
+
+	purgeQueue := &cobra.Command{
+		Use:   "queue <ADDRESS> <NAME>",
+		Short: "Remove all ready messages from a queue",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPurgeQueue(purgeQueueOptions, args)
+		},
+	}
+
+	purgeQueue.Flags().
+		BoolVarP(&purgeQueueOptions.force, "force", "f", false, "force running this command without opt-in")
+
+	return purgeQueue
+}
+
+// runPurgeQueue purges a queue by reading the command line data, setting the
+// configuration and calling the PurgeQueue function. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+//
+// Since purging a queue deletes its messages, the user has to confirm this unless
+// the --force flag is used.
+func runPurgeQueue(options *purgeQueueOptions, args []string) error {
+	var (
+		address = args[0]
+		name    = args[1]
+	)
+
+	message := fmt.Sprintf("Purging the queue %s will delete all messages that are ready for "+
+		"delivery. Do you want to continue?", name)
+
+	if !options.force {
+		ok := confirm(options.globalOptions, message)
+		if !ok {
+			return nil
+		}
+	}
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	if err := provider.PurgeQueue(Queue{Name: name}); err != nil {
+		return err
+	}
+
+	options.printSuccess("queue purged successfully")
+
+	return nil
+}
+
+// exportOptions defines options for exporting the topology.
+type exportOptions struct {
+	*globalOptions
+	all bool
+}
+
+// exportCommand creates the `buneary export` command, making sure that exactly one
+// argument is passed.
+func exportCommand(options *globalOptions) *cobra.Command {
+	exportOptions := &exportOptions{
+		globalOptions: options,
+	}
+
+	export := &cobra.Command{
+		Use:   "export <ADDRESS>",
+		Short: "Export exchanges, queues and bindings as YAML",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(exportOptions, args)
+		},
+	}
+
+	export.Flags().
+		BoolVar(&exportOptions.all, "all", false, "include pre-defined, server-named and exclusive resources")
+
+	return export
+}
+
+// runExport exports the topology by reading the command line data, setting the
+// configuration and calling the ExportTopology function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// The topology is written to the standard output as YAML, so that it can easily be
+// redirected into a file.
+func runExport(options *exportOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	topology, err := ExportTopology(provider, options.all)
+	if err != nil {
+		return err
+	}
+
+	output, err := yaml.Marshal(topology)
+	if err != nil {
+		return fmt.Errorf("marshalling topology: %w", err)
+	}
+
+	_, _ = options.out.WriteString(string(output))
+
+	return nil
+}
+
+// definitionsCommand creates the `buneary definitions` command without any functionality.
+func definitionsCommand(options *globalOptions) *cobra.Command {
+	definitions := &cobra.Command{
+		Use:   "definitions <COMMAND>",
+		Short: "Export or import server definitions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	definitions.AddCommand(definitionsExportCommand(options))
+	definitions.AddCommand(definitionsImportCommand(options))
+
+	return definitions
+}
+
+// definitionsExportOptions defines options for exporting definitions.
+type definitionsExportOptions struct {
+	*globalOptions
+	filter []string
+}
+
+// definitionsExportCommand creates the `buneary definitions export` command, making
+// sure that exactly one argument is passed.
+func definitionsExportCommand(options *globalOptions) *cobra.Command {
+	definitionsExportOptions := &definitionsExportOptions{
+		globalOptions: options,
+	}
+
+	definitionsExport := &cobra.Command{
+		Use:   "export <ADDRESS>",
+		Short: "Export the server definitions as JSON",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDefinitionsExport(definitionsExportOptions, args)
+		},
+	}
+
+	definitionsExport.Flags().
+		StringSliceVar(&definitionsExportOptions.filter, "filter", nil, "only include the given resource kinds")
+
+	return definitionsExport
+}
+
+// runDefinitionsExport exports the definitions by reading the command line data, setting
+// the configuration and calling the GetDefinitions function. In case the password or
+// both the user and password aren't provided, it will go into interactive mode.
+//
+// If the --vhost flag is used, only the definitions of that virtual host are exported.
+func runDefinitionsExport(options *definitionsExportOptions, args []string) error {
+	var (
+		address = args[0]
+	)
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	definitions, err := provider.GetDefinitions(options.vhost)
+	if err != nil {
+		return err
+	}
+
+	if err := definitions.Filter(options.filter); err != nil {
+		return err
+	}
+
+	output, err := json.MarshalIndent(definitions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling definitions: %w", err)
+	}
+
+	_, _ = options.out.WriteString(string(output) + "\n")
+
+	return nil
+}
+
+// definitionsImportOptions defines options for importing definitions.
+type definitionsImportOptions struct {
+	*globalOptions
+	filter []string
+}
+
+// definitionsImportCommand creates the `buneary definitions import` command, making
+// sure that exactly two arguments are passed.
+func definitionsImportCommand(options *globalOptions) *cobra.Command {
+	definitionsImportOptions := &definitionsImportOptions{
+		globalOptions: options,
+	}
+
+	definitionsImport := &cobra.Command{
+		Use:   "import <ADDRESS> <FILE>",
+		Short: "Import server definitions from a JSON file",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDefinitionsImport(definitionsImportOptions, args)
+		},
+	}
+
+	definitionsImport.Flags().
+		StringSliceVar(&definitionsImportOptions.filter, "filter", nil, "only include the given resource kinds")
+
+	return definitionsImport
+}
+
+// runDefinitionsImport imports definitions by reading the command line data, setting
+// the configuration and calling the ImportDefinitions function. In case the password
+// or both the user and password aren't provided, it will go into interactive mode.
+//
+// If the global --dry-run flag is set, the definitions are compared to the current server
+// definitions and all resources that don't exist on the server yet are printed instead
+// of the import request.
+func runDefinitionsImport(options *definitionsImportOptions, args []string) error {
+	var (
+		address = args[0]
+		file    = args[1]
+	)
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("reading definitions file: %w", err)
+	}
+
+	definitions := &Definitions{}
+
+	if err := json.Unmarshal(content, definitions); err != nil {
+		return fmt.Errorf("parsing definitions file: %w", err)
+	}
+
+	if err := definitions.Filter(options.filter); err != nil {
+		return err
+	}
+
+	user, password := getOrReadInCredentials(options.globalOptions)
+
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    options.vhost,
+	})
+
+	if !options.dryRun {
+		if err := provider.ImportDefinitions(options.vhost, definitions); err != nil {
+			return err
+		}
+
+		options.printSuccess("definitions imported successfully")
+
+		return nil
+	}
+
+	current, err := provider.GetDefinitions(options.vhost)
+	if err != nil {
+		return err
+	}
+
+	changes := current.Additions(definitions, options.vhost)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Kind", "Definition"})
+
+	for _, change := range changes {
+		row := make([]string, 2)
+		row[0] = change.Kind
+		row[1] = change.Identifier
 		table.Append(row)
 	}
 
-	table.Render()
+	table.Render()
+
+	output := fmt.Sprintf("%d definitions would be added\n", len(changes))
+	_, _ = options.out.WriteString(output)
+
+	return nil
+}
+
+// cloneOptions defines options for cloning the topology.
+type cloneOptions struct {
+	*globalOptions
+	srcUser      string
+	srcPassword  string
+	srcVhost     string
+	destUser     string
+	destPassword string
+	destVhost    string
+	include      string
+	exclude      string
+}
+
+// cloneCommand creates the `buneary clone` command, making sure that exactly two
+// arguments are passed.
+func cloneCommand(options *globalOptions) *cobra.Command {
+	cloneOptions := &cloneOptions{
+		globalOptions: options,
+	}
+
+	clone := &cobra.Command{
+		Use:   "clone <SRC ADDRESS> <DEST ADDRESS>",
+		Short: "Copy exchanges, queues and bindings to another server",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runClone(cloneOptions, args)
+		},
+	}
+
+	clone.Flags().
+		StringVar(&cloneOptions.srcUser, "src-user", "", "the username for the source server")
+	clone.Flags().
+		StringVar(&cloneOptions.srcPassword, "src-password", "", "the password for the source server")
+	clone.Flags().
+		StringVar(&cloneOptions.srcVhost, "src-vhost", "", "the virtual host to read from")
+	clone.Flags().
+		StringVar(&cloneOptions.destUser, "dest-user", "", "the username for the destination server")
+	clone.Flags().
+		StringVar(&cloneOptions.destPassword, "dest-password", "", "the password for the destination server")
+	clone.Flags().
+		StringVar(&cloneOptions.destVhost, "dest-vhost", "", "the virtual host to declare the resources in")
+	clone.Flags().
+		StringVar(&cloneOptions.include, "include", "", "only clone resources whose names match this regex")
+	clone.Flags().
+		StringVar(&cloneOptions.exclude, "exclude", "", "skip resources whose names match this regex")
+
+	return clone
+}
+
+// runClone reads the topology from the source server and declares it on the destination
+// server. Both servers have their own credentials, which default to the global --user
+// and --password flags. If they're still missing, it will go into interactive mode.
+//
+// Both virtual hosts default to the global --vhost flag and finally to `/`. Choosing
+// different virtual hosts allows to remap the resources to another virtual host.
+//
+// If the global --dry-run flag is set, the resources that would be declared are printed
+// instead of the requests for declaring them.
+func runClone(options *cloneOptions, args []string) error {
+	var (
+		srcAddress  = args[0]
+		destAddress = args[1]
+	)
+
+	match, err := nameMatcher(options.include, options.exclude)
+	if err != nil {
+		return err
+	}
+
+	srcProvider := newProvider(options.globalOptions, cloneConfig(options.globalOptions, srcAddress,
+		options.srcUser, options.srcPassword, options.srcVhost))
+
+	topology, err := ExportTopology(srcProvider, false)
+	if err != nil {
+		return err
+	}
+
+	topology.Filter(match)
+
+	if options.dryRun {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Kind", "Name", "Details"})
+
+		for _, exchange := range topology.Exchanges {
+			table.Append([]string{"exchange", exchange.Name, string(exchange.Type)})
+		}
+
+		for _, queue := range topology.Queues {
+			table.Append([]string{"queue", queue.Name, string(queue.Type)})
+		}
+
+		for _, binding := range topology.Bindings {
+			details := fmt.Sprintf("%s %s (%s)", binding.DestinationType, binding.Destination, binding.RoutingKey)
+			table.Append([]string{"binding", binding.Source, details})
+		}
+
+		table.Render()
+
+		return nil
+	}
+
+	destProvider := newProvider(options.globalOptions, cloneConfig(options.globalOptions, destAddress,
+		options.destUser, options.destPassword, options.destVhost))
+
+	if err := ApplyTopology(destProvider, topology); err != nil {
+		return err
+	}
+
+	options.printSuccess(fmt.Sprintf("cloned %d exchanges, %d queues and %d bindings successfully",
+		len(topology.Exchanges), len(topology.Queues), len(topology.Bindings)))
 
 	return nil
 }
 
-// getMessagesOptions defines options for reading messages.
-type getMessagesOptions struct {
+// cloneConfig builds the configuration for one side of `buneary clone`. Credentials and
+// the virtual host that haven't been specified fall back to the global options. If the
+// credentials are still incomplete, the user will be asked to type them in.
+func cloneConfig(options *globalOptions, address, user, password, vhost string) *RabbitMQConfig {
+	credentials := &globalOptions{
+		user:     user,
+		password: password,
+		out:      options.out,
+	}
+
+	if credentials.user == "" {
+		credentials.user = options.user
+	}
+
+	if credentials.password == "" {
+		credentials.password = options.password
+	}
+
+	if credentials.user == "" || credentials.password == "" {
+		_, _ = options.out.WriteString(fmt.Sprintf("Credentials for %s\n", address))
+	}
+
+	user, password = getOrReadInCredentials(credentials)
+
+	// Without an explicit virtual host, the resources of all virtual hosts would be
+	// read from the source server. Hence, the default virtual host is used instead.
+	if vhost == "" {
+		vhost = options.vhost
+	}
+
+	if vhost == "" {
+		vhost = "/"
+	}
+
+	return &RabbitMQConfig{
+		Address:  address,
+		User:     user,
+		Password: password,
+		Vhost:    vhost,
+	}
+}
+
+// nameMatcher returns a function that matches resource names against the given include
+// and exclude regular expressions. An empty expression won't be taken into account.
+func nameMatcher(include, exclude string) (func(name string) bool, error) {
+	var includeExpr, excludeExpr *regexp.Regexp
+
+	if include != "" {
+		expr, err := regexp.Compile(include)
+		if err != nil {
+			return nil, fmt.Errorf("parsing include expression: %w", err)
+		}
+		includeExpr = expr
+	}
+
+	if exclude != "" {
+		expr, err := regexp.Compile(exclude)
+		if err != nil {
+			return nil, fmt.Errorf("parsing exclude expression: %w", err)
+		}
+		excludeExpr = expr
+	}
+
+	match := func(name string) bool {
+		if includeExpr != nil && !includeExpr.MatchString(name) {
+			return false
+		}
+		if excludeExpr != nil && excludeExpr.MatchString(name) {
+			return false
+		}
+		return true
+	}
+
+	return match, nil
+}
+
+// graphOptions defines options for rendering the topology graph.
+type graphOptions struct {
 	*globalOptions
-	max     int
-	requeue bool
-	force   bool
+	format string
+	from   string
 }
 
-// getMessagesCommand creates the `buneary get messages` command, making sure that exactly
-// two arguments are passed.
-func getMessagesCommand(options *globalOptions) *cobra.Command {
-	getMessagesOptions := &getMessagesOptions{
+// graphCommand creates the `buneary graph` command, making sure that exactly one
+// argument is passed.
+func graphCommand(options *globalOptions) *cobra.Command {
+	graphOptions := &graphOptions{
 		globalOptions: options,
 	}
 
-	getMessages := &cobra.Command{
-		Use:   "messages <ADDRESS> <QUEUE NAME>",
-		Short: "Get messages in a queue",
-		Args:  cobra.ExactArgs(2),
+	graph := &cobra.Command{
+		Use:   "graph <ADDRESS>",
+		Short: "Render exchanges, queues and bindings as graph",
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runGetMessages(getMessagesOptions, args)
+			return runGraph(graphOptions, args)
 		},
 	}
 
-	getMessages.Flags().
-		IntVar(&getMessagesOptions.max, "max", 1, "maximum messages to read")
-	getMessages.Flags().
-		BoolVar(&getMessagesOptions.requeue, "requeue", false, "re-queue the messages after reading them")
-	getMessages.Flags().
-		BoolVarP(&getMessagesOptions.force, "force", "f", false, "force running this command without opt-in")
+	graph.Flags().
+		StringVar(&graphOptions.format, "format", string(DOT), "the output format, either dot or mermaid")
+	graph.Flags().
+		StringVar(&graphOptions.from, "from", "", "only render resources reachable from this exchange")
 
-	return getMessages
+	return graph
 }
 
-// runGetMessages gets messages by reading the command line data, setting the
-// configuration and calling the GetMessages function. In case the password or
-// both the user and password aren't provided, it will go into interactive mode.
-func runGetMessages(options *getMessagesOptions, args []string) error {
+// runGraph renders the topology graph by reading the command line data, setting the
+// configuration and calling the BuildGraph function. In case the password or both
+// the user and password aren't provided, it will go into interactive mode.
+func runGraph(options *graphOptions, args []string) error {
 	var (
 		address = args[0]
-		queue   = args[1]
 	)
 
-	message := "Reading the messages from the queue will de-queue them." +
-		"To re-queue them, pass the --requeue flag. Do you want to continue?"
-
-	if !options.force {
-		ok := confirm(options.globalOptions, message)
-		if !ok {
-			return nil
-		}
-	}
-
 	user, password := getOrReadInCredentials(options.globalOptions)
 
-	provider := NewProvider(&RabbitMQConfig{
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
 		Address:  address,
 		User:     user,
 		Password: password,
+		Vhost:    targetVhost(options.globalOptions),
 	})
 
-	messages, err := provider.GetMessages(Queue{Name: queue}, options.max, options.requeue)
+	graph, err := BuildGraph(provider, options.from)
 	if err != nil {
 		return err
 	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Exchange", "Routing Key", "Body"})
-
-	for _, message := range messages {
-		row := make([]string, 3)
-		row[0] = message.Target.Name
-		row[1] = message.RoutingKey
-		row[2] = string(message.Body)
-		table.Append(row)
+	output, err := graph.Render(GraphFormat(options.format))
+	if err != nil {
+		return err
 	}
 
-	table.Render()
+	_, _ = options.out.WriteString(output)
 
 	return nil
 }
 
-// publishOptions defines options for publishing a message.
-type publishOptions struct {
+// routeOptions defines options for simulating the routing of a message.
+type routeOptions struct {
 	*globalOptions
 	headers string
 }
 
-// publishCommand creates the `buneary publish` command, making sure that exactly
-// four command arguments are passed.
-func publishCommand(options *globalOptions) *cobra.Command {
-	publishOptions := &publishOptions{
+// routeCommand creates the `buneary route` command, making sure that exactly three
+// command arguments are passed.
+func routeCommand(options *globalOptions) *cobra.Command {
+	routeOptions := &routeOptions{
 		globalOptions: options,
 	}
 
-	publish := &cobra.Command{
-		Use:   "publish <ADDRESS> <EXCHANGE> <ROUTING KEY> <BODY>",
-		Short: "Publish a message to an exchange",
-		Args:  cobra.ExactArgs(4),
+	route := &cobra.Command{
+		Use:   "route <ADDRESS> <EXCHANGE> <ROUTING KEY>",
+		Short: "Show the queues a message would be routed to",
+		Args:  cobra.ExactArgs(3),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runPublish(publishOptions, args)
+			return runRoute(routeOptions, args)
 		},
 	}
 
-	publish.Flags().
-		StringVar(&publishOptions.headers, "headers", "", "headers as comma-separated key-value pairs")
+	route.Flags().
+		StringVar(&routeOptions.headers, "headers", "", "headers as comma-separated key-value pairs")
 
-	return publish
+	return route
 }
 
-// runPublish publishes a message by reading the command line data, setting the
-// configuration and calling the PublishMessage function. In case the password or
-// both the user and password aren't provided, it will go into interactive mode.
-func runPublish(options *publishOptions, args []string) error {
+// runRoute simulates the routing of a message by reading the command line data, setting
+// the configuration and calling the Route function. In case the password or both the
+// user and password aren't provided, it will go into interactive mode.
+//
+// Nothing will be published. Instead, the path from the exchange to each queue that
+// would receive the message is printed.
+func runRoute(options *routeOptions, args []string) error {
 	var (
 		address    = args[0]
 		exchange   = args[1]
 		routingKey = args[2]
-		body       = args[3]
 	)
 
+	headers, err := parseHeaders(options.headers)
+	if err != nil {
+		return err
+	}
+
 	user, password := getOrReadInCredentials(options.globalOptions)
 
-	provider := NewProvider(&RabbitMQConfig{
+	provider := newProvider(options.globalOptions, &RabbitMQConfig{
 		Address:  address,
 		User:     user,
 		Password: password,
+		Vhost:    targetVhost(options.globalOptions),
 	})
 
-	message := Message{
-		Target:     Exchange{Name: exchange},
-		Headers:    make(map[string]interface{}),
-		RoutingKey: routingKey,
-		Body:       []byte(body),
+	results, err := Route(provider, exchange, routingKey, headers)
+	if err != nil {
+		return err
 	}
 
-	if options.headers != "" {
-		// Parse the message headers in the form key1=val1,key2=val2. If the headers
-		// do not adhere to this syntax, an error is returned. In case the same key
-		// exists multiple times, the last one wins.
-		for _, header := range strings.Split(options.headers, ",") {
-			tokens := strings.Split(strings.TrimSpace(header), "=")
+	if len(results) == 0 {
+		_, _ = options.out.WriteString("message would be unroutable\n")
+		return nil
+	}
 
-			if len(tokens) != 2 {
-				return errors.New("expected header in form key=value")
-			}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Queue", "Path"})
 
-			key := tokens[0]
-			value := tokens[1]
+	for _, result := range results {
+		path := []string{exchange}
 
-			message.Headers[key] = value
+		for _, step := range result.Path {
+			if step.Alternate {
+				path = append(path, fmt.Sprintf("(alternate) %s", step.Binding.TargetName))
+				continue
+			}
+			path = append(path, fmt.Sprintf("[%s] %s", step.Binding.Key, step.Binding.TargetName))
 		}
-	}
 
-	if err := provider.PublishMessage(message); err != nil {
-		return err
+		row := make([]string, 2)
+		row[0] = result.Queue
+		row[1] = strings.Join(path, " -> ")
+		table.Append(row)
 	}
 
-	_, _ = options.out.WriteString("message published successfully\n")
+	table.Render()
 
 	return nil
 }
 
-// deleteCommand creates the `buneary delete` command without any functionality.
-func deleteCommand(options *globalOptions) *cobra.Command {
-	delete := &cobra.Command{
-		Use:   "delete <COMMAND>",
-		Short: "Delete a resource",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return cmd.Help()
-		},
-	}
-
-	delete.AddCommand(deleteExchangeCommand(options))
-	delete.AddCommand(deleteQueueCommand(options))
-
-	return delete
-}
-
-// deleteExchangeCommand creates the `buneary delete exchange` command, making sure
-// that exactly two arguments are passed.
-func deleteExchangeCommand(options *globalOptions) *cobra.Command {
-	deleteExchange := &cobra.Command{
-		Use:   "exchange <ADDRESS> <NAME>",
-		Short: "Delete an exchange",
-		Args:  cobra.ExactArgs(2),
+// statusCommand creates the `buneary status` command, making sure that exactly one
+// argument is passed.
+func statusCommand(options *globalOptions) *cobra.Command {
+	status := &cobra.Command{
+		Use:   "status <ADDRESS>",
+		Short: "Show the cluster status and node health",
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDeleteExchange(options, args)
+			return runStatus(options, args)
 		},
 	}
 
-	return deleteExchange
+	return status
 }
 
-// runDeleteExchange deletes an exchange by reading the command line data, setting the
-// configuration and calling the DeleteExchange function. In case the password or
-// both the user and password aren't provided, it will go into interactive mode.
-func runDeleteExchange(options *globalOptions, args []string) error {
+// runStatus prints an overview of the cluster, its nodes and the health check results.
+// In case the password or both the user and password aren't provided, it will go into
+// interactive mode.
+//
+// If any alarm is active, an error is returned after printing the status, so that the
+// command exits with a non-zero code and can be used as health check in CI pipelines.
+func runStatus(options *globalOptions, args []string) error {
 	var (
 		address = args[0]
-		name    = args[1]
 	)
 
 	user, password := getOrReadInCredentials(options)
 
-	provider := NewProvider(&RabbitMQConfig{
+	provider := newProvider(options, &RabbitMQConfig{
 		Address:  address,
 		User:     user,
 		Password: password,
+		Vhost:    options.vhost,
 	})
 
-	exchange := Exchange{
-		Name: name,
+	status, err := provider.GetStatus()
+	if err != nil {
+		return err
 	}
 
-	if err := provider.DeleteExchange(exchange); err != nil {
-		return err
+	overview := fmt.Sprintf("Cluster:  %s\n", status.ClusterName) +
+		fmt.Sprintf("RabbitMQ: %s\n", status.RabbitMQVersion) +
+		fmt.Sprintf("Erlang:   %s\n", status.ErlangVersion) +
+		fmt.Sprintf("Messages: %d (%d ready, %d unacked)\n", status.Messages, status.MessagesReady, status.MessagesUnAck) +
+		fmt.Sprintf("Rates:    %.1f/s published, %.1f/s delivered, %.1f/s acked\n\n", status.PublishRate, status.DeliverRate, status.AckRate)
+
+	_, _ = options.out.WriteString(overview)
+
+	nodes := tablewriter.NewWriter(os.Stdout)
+	nodes.SetHeader([]string{"Node", "Running", "Memory", "Memory Alarm", "Disk Free", "Disk Alarm", "File Descriptors", "Partitions"})
+
+	for _, node := range status.Nodes {
+		row := make([]string, 8)
+		row[0] = node.Name
+		row[1] = boolToString(node.Running)
+		row[2] = fmt.Sprintf("%d/%d", node.MemoryUsed, node.MemoryLimit)
+		row[3] = boolToString(node.MemoryAlarm)
+		row[4] = fmt.Sprintf("%d/%d", node.DiskFree, node.DiskFreeLimit)
+		row[5] = boolToString(node.DiskFreeAlarm)
+		row[6] = fmt.Sprintf("%d/%d", node.FileDescriptorsUsed, node.FileDescriptorsTotal)
+		row[7] = strings.Join(node.Partitions, ", ")
+		nodes.Append(row)
 	}
 
-	_, _ = options.out.WriteString("exchange deleted successfully\n")
+	nodes.Render()
+
+	checks := tablewriter.NewWriter(os.Stdout)
+	checks.SetHeader([]string{"Health Check", "Passed", "Reason"})
+
+	for _, check := range status.HealthChecks {
+		row := make([]string, 3)
+		row[0] = check.Name
+		row[1] = boolToString(check.Passed)
+		row[2] = check.Reason
+		checks.Append(row)
+	}
+
+	checks.Render()
+
+	if status.Alarms() {
+		return errors.New("the cluster has active alarms")
+	}
 
 	return nil
 }
 
-// deleteQueueCommand creates the `buneary delete queue` command, making sure
-// that exactly two arguments are passed.
-func deleteQueueCommand(options *globalOptions) *cobra.Command {
-	deleteExchange := &cobra.Command{
-		Use:   "queue <ADDRESS> <NAME>",
-		Short: "Delete a queue",
+// uiCommand creates the `buneary ui` command, making sure that exactly one argument
+// is passed.
+func uiCommand(options *globalOptions) *cobra.Command {
+	ui := &cobra.Command{
+		Use:   "ui <ADDRESS>",
+		Short: "Browse the server in an interactive terminal UI",
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runDeleteQueue(options, args)
+			return runUI(options, args)
 		},
 	}
 
-	return deleteExchange
+	return ui
 }
 
-// runDeleteQueue deletes a queue by reading the command line data, setting the
-// configuration and calling the DeleteQueue function. In case the password or
-// both the user and password aren't provided, it will go into interactive mode.
-func runDeleteQueue(options *globalOptions, args []string) error {
+// runUI runs the interactive terminal UI until the user quits. In case the password
+// or both the user and password aren't provided, it will go into interactive mode.
+func runUI(options *globalOptions, args []string) error {
 	var (
 		address = args[0]
-		name    = args[1]
 	)
 
 	user, password := getOrReadInCredentials(options)
 
-	provider := NewProvider(&RabbitMQConfig{
+	provider := newProvider(options, &RabbitMQConfig{
 		Address:  address,
 		User:     user,
 		Password: password,
+		Vhost:    targetVhost(options),
 	})
 
-	queue := Queue{
-		Name: name,
-	}
-
-	if err := provider.DeleteQueue(queue); err != nil {
-		return err
-	}
-
-	_, _ = options.out.WriteString("queue deleted successfully\n")
-
-	return nil
+	return newTerminalUI(provider).run()
 }
 
 // versionCommand creates the `buneary version` command for printing release
@@ -875,6 +3539,22 @@ func versionCommand(options *globalOptions) *cobra.Command {
 	return version
 }
 
+// newProvider creates a new Provider for the given configuration. If the --dry-run
+// flag is set, the provider only prints the requests of mutating operations.
+func newProvider(options *globalOptions, config *RabbitMQConfig) Provider {
+	factory := options.providerFactory
+
+	if factory == nil {
+		factory = NewProvider
+	}
+
+	if options.dryRun {
+		return newDryRunProvider(factory(config), config, options.out)
+	}
+
+	return factory(config)
+}
+
 // getOrReadInCredentials either returns the credentials directly from the global
 // options or prompts the user to type them in.
 //
@@ -898,7 +3578,7 @@ func getOrReadInCredentials(options *globalOptions) (string, string) {
 	user, _ = reader.ReadString('\n')
 	user = strings.TrimSpace(user)
 
-	signalCh := make(chan os.Signal)
+	signalCh := make(chan os.Signal, 1)
 	signal.Notify(signalCh, os.Interrupt)
 
 	go func() {
@@ -936,6 +3616,99 @@ func confirm(options *globalOptions, message string) bool {
 	return answer == "y" || answer == "yes"
 }
 
+// parseHeaders parses message headers in the form key1=val1,key2=val2. If the headers
+// do not adhere to this syntax, an error is returned. In case the same key exists
+// multiple times, the last one wins.
+func parseHeaders(source string) (map[string]interface{}, error) {
+	headers := make(map[string]interface{})
+
+	if source == "" {
+		return headers, nil
+	}
+
+	for _, header := range strings.Split(source, ",") {
+		tokens := strings.Split(strings.TrimSpace(header), "=")
+
+		if len(tokens) != 2 {
+			return nil, errors.New("expected header in form key=value")
+		}
+
+		key := tokens[0]
+		value := tokens[1]
+
+		headers[key] = value
+	}
+
+	return headers, nil
+}
+
+// parseArguments parses resource arguments given as list of key=value pairs. Unlike
+// parseHeaders, the values may contain commas. In case the same key exists multiple
+// times, the last one wins.
+func parseArguments(source []string) (map[string]interface{}, error) {
+	arguments := make(map[string]interface{})
+
+	for _, argument := range source {
+		tokens := strings.SplitN(argument, "=", 2)
+
+		if len(tokens) != 2 || tokens[0] == "" {
+			return nil, errors.New("expected argument in form key=value")
+		}
+
+		arguments[tokens[0]] = tokens[1]
+	}
+
+	return arguments, nil
+}
+
+// hasArguments determines whether the given arguments contain all expected arguments
+// with the same values. The values are compared by their string representation.
+func hasArguments(arguments, expected map[string]interface{}) bool {
+	for key, value := range expected {
+		actual, ok := arguments[key]
+		if !ok || fmt.Sprint(actual) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// argumentsToString returns the given arguments as comma-separated key-value pairs,
+// sorted by their keys.
+func argumentsToString(arguments map[string]interface{}) string {
+	pairs := make([]string, 0, len(arguments))
+
+	for key, value := range arguments {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, value))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ", ")
+}
+
+// clientPropertiesToString returns the connection name, product and version from the
+// given client properties in the form `name (product version)`. Other properties like
+// the client capabilities are omitted.
+func clientPropertiesToString(properties map[string]interface{}) string {
+	var (
+		name, _    = properties["connection_name"].(string)
+		product, _ = properties["product"].(string)
+		version, _ = properties["version"].(string)
+		client     = strings.TrimSpace(product + " " + version)
+	)
+
+	if name == "" {
+		return client
+	}
+
+	if client == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s (%s)", name, client)
+}
+
 // boolToString returns "yes" if the given bool is true and "no" if it is false.
 func boolToString(source bool) string {
 	if source {
@@ -0,0 +1,595 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cliTest runs buneary commands against InMemoryProviders. Each server address has its
+// own provider, so that commands involving two servers like `clone` can be tested.
+type cliTest struct {
+	t       *testing.T
+	servers map[string]*InMemoryProvider
+
+	// configs contains the configurations of all providers created so far.
+	configs []RabbitMQConfig
+}
+
+// newCLITest creates a new cliTest with a single server at `localhost`.
+func newCLITest(t *testing.T) *cliTest {
+	return &cliTest{
+		t: t,
+		servers: map[string]*InMemoryProvider{
+			"localhost": NewInMemoryProvider(),
+		},
+	}
+}
+
+// server returns the provider for the given address, creating it if necessary.
+func (c *cliTest) server(address string) *InMemoryProvider {
+	if _, ok := c.servers[address]; !ok {
+		c.servers[address] = NewInMemoryProvider()
+	}
+	return c.servers[address]
+}
+
+// run runs buneary with the given arguments and returns everything written to the
+// output, including tables written to os.Stdout directly. Credentials are passed
+// automatically, so that buneary doesn't ask for them.
+func (c *cliTest) run(args ...string) (string, error) {
+	c.t.Helper()
+
+	var output bytes.Buffer
+
+	options := globalOptions{
+		out: &output,
+		providerFactory: func(config *RabbitMQConfig) Provider {
+			c.configs = append(c.configs, *config)
+			return c.server(config.Address)
+		},
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		c.t.Fatalf("creating pipe: %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = writer
+
+	captured := make(chan string)
+
+	go func() {
+		var buffer bytes.Buffer
+		_, _ = io.Copy(&buffer, reader)
+		captured <- buffer.String()
+	}()
+
+	root := rootCommand(&options)
+	root.SetArgs(append(args, "--user", "guest", "--password", "guest"))
+
+	err = root.Execute()
+
+	os.Stdout = stdout
+	_ = writer.Close()
+
+	return output.String() + <-captured, err
+}
+
+// mustRun works like run, but fails the test if the command returns an error.
+func (c *cliTest) mustRun(args ...string) string {
+	c.t.Helper()
+
+	output, err := c.run(args...)
+	if err != nil {
+		c.t.Fatalf("running %s: %v", strings.Join(args, " "), err)
+	}
+
+	return output
+}
+
+// expectOutput fails the test unless the output contains all given strings.
+func expectOutput(t *testing.T, output string, expected ...string) {
+	t.Helper()
+
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("expected output to contain %q, got:\n%s", e, output)
+		}
+	}
+}
+
+// seed creates an exchange `orders` bound to the queues `created` and `all`, as well
+// as a user `alice` with permissions and a policy `ha`. `alice` is connected with a
+// single channel consuming from `all`.
+func (c *cliTest) seed() {
+	c.t.Helper()
+
+	c.mustRun("create", "exchange", "localhost", "orders", "topic", "--durable")
+	c.mustRun("create", "queue", "localhost", "created", "quorum", "--durable")
+	c.mustRun("create", "queue", "localhost", "all", "classic")
+	c.mustRun("create", "binding", "localhost", "orders", "created", "order.created")
+	c.mustRun("create", "binding", "localhost", "orders", "all", "#")
+	c.mustRun("create", "user", "localhost", "alice", "--new-password", "secret", "--tags", "monitoring")
+	c.mustRun("set", "permissions", "localhost", "alice", ".*", ".*", ".*")
+	c.mustRun("create", "policy", "localhost", "ha", "^created$", `{"max-length": 100}`, "--apply-to", "queues")
+
+	c.server("localhost").Connect(
+		Connection{Name: seedConnection, User: "alice", Vhost: "/", PeerHost: "10.0.0.1", PeerPort: 5000, State: "running", ClientProperties: map[string]interface{}{"product": "billing"}},
+		[]Channel{{Name: seedConnection + " (1)", Number: 1, User: "alice", Vhost: "/", Prefetch: 10}},
+		[]Consumer{{Tag: "ctag-billing", Queue: "all", Vhost: "/", Channel: seedConnection + " (1)", AckRequired: true, Prefetch: 10}},
+	)
+}
+
+// seedConnection is the name of the connection created by cliTest.seed.
+const seedConnection = "10.0.0.1:5000 -> 127.0.0.1:5672"
+
+func TestCreateCommands(t *testing.T) {
+	c := newCLITest(t)
+
+	expectOutput(t, c.mustRun("create", "exchange", "localhost", "orders", "direct", "--durable"),
+		"exchange created successfully")
+	expectOutput(t, c.mustRun("create", "queue", "localhost", "created", "classic"),
+		"queue created successfully")
+	expectOutput(t, c.mustRun("create", "queue", "localhost", "classic"),
+		"queue amq.gen-1 created successfully")
+	expectOutput(t, c.mustRun("create", "binding", "localhost", "orders", "created", "order.created"),
+		"created successfully")
+	expectOutput(t, c.mustRun("create", "binding", "localhost", "orders", "amq.topic", "order.#", "--to-exchange"),
+		"created successfully")
+	expectOutput(t, c.mustRun("create", "user", "localhost", "alice", "--new-password", "secret"),
+		"user created successfully")
+	expectOutput(t, c.mustRun("create", "vhost", "localhost", "staging", "--description", "Staging"),
+		"vhost created successfully")
+	expectOutput(t, c.mustRun("create", "policy", "localhost", "limit", ".*", `{"max-length": 10}`),
+		"policy created successfully")
+
+	if _, err := c.run("create", "exchange", "localhost", "orders", "fanout", "--durable"); err == nil {
+		t.Error("expected re-declaring an exchange with a different type to fail")
+	}
+
+	if _, err := c.run("create", "binding", "localhost", "orders", "missing", "key"); err == nil {
+		t.Error("expected creating a binding to a missing queue to fail")
+	}
+
+	bindings, err := c.server("localhost").GetBindings(func(binding Binding) bool {
+		return binding.From.Name == "orders"
+	})
+	if err != nil {
+		t.Fatalf("listing bindings: %v", err)
+	}
+
+	if len(bindings) != 2 || bindings[1].Type != ToExchange {
+		t.Errorf("expected a queue and an exchange binding, got %v", bindings)
+	}
+}
+
+func TestGetCommands(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	testCases := map[string]struct {
+		args     []string
+		expected []string
+	}{
+		"get exchanges":        {args: []string{"get", "exchanges", "localhost"}, expected: []string{"orders", "amq.topic"}},
+		"get exchange":         {args: []string{"get", "exchange", "localhost", "orders"}, expected: []string{"orders", "topic"}},
+		"get queues":           {args: []string{"get", "queues", "localhost"}, expected: []string{"created", "all", "ha"}},
+		"get queue":            {args: []string{"get", "queue", "localhost", "created"}, expected: []string{"created", "ha"}},
+		"get bindings":         {args: []string{"get", "bindings", "localhost"}, expected: []string{"order.created", "#"}},
+		"get binding":          {args: []string{"get", "binding", "localhost", "orders", "created"}, expected: []string{"order.created"}},
+		"get users":            {args: []string{"get", "users", "localhost"}, expected: []string{"alice", "monitoring"}},
+		"get permissions":      {args: []string{"get", "permissions", "localhost", "alice"}, expected: []string{"alice", ".*"}},
+		"get vhosts":           {args: []string{"get", "vhosts", "localhost"}, expected: []string{"/"}},
+		"get policies":         {args: []string{"get", "policies", "localhost"}, expected: []string{"ha", "^created$"}},
+		"get connections":      {args: []string{"get", "connections", "localhost"}, expected: []string{"10.0.0.1:5000 ->", "alice", "10.0.0.1:5000", "billing", "running"}},
+		"get channels":         {args: []string{"get", "channels", "localhost"}, expected: []string{"CONNECTION", "10.0.0.1:5000 ->", "alice", " 10 |", " 1 |"}},
+		"get consumers":        {args: []string{"get", "consumers", "localhost"}, expected: []string{"ctag-billing", "manual"}},
+		"filtered queues":      {args: []string{"get", "queues", "localhost", "--filter", "type=quorum"}, expected: []string{"created"}},
+		"sorted exchanges":     {args: []string{"get", "exchanges", "localhost", "--sort-by", "name:desc", "--limit", "1"}, expected: []string{"orders"}},
+		"filtered bindings":    {args: []string{"get", "bindings", "localhost", "--filter", "key=#"}, expected: []string{"all"}},
+		"exported topology":    {args: []string{"export", "localhost"}, expected: []string{"orders", "order.created"}},
+		"exported definitions": {args: []string{"definitions", "export", "localhost"}, expected: []string{`"name": "orders"`}},
+		"graph":                {args: []string{"graph", "localhost"}, expected: []string{"orders", "created"}},
+		"route":                {args: []string{"route", "localhost", "orders", "order.created"}, expected: []string{"created", "all"}},
+		"status":               {args: []string{"status", "localhost"}, expected: []string{"rabbit@localhost"}},
+		"version":              {args: []string{"version"}, expected: []string{version}},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			expectOutput(t, c.mustRun(testCase.args...), testCase.expected...)
+		})
+	}
+
+	output := c.mustRun("get", "queues", "localhost", "--filter", "type=quorum")
+	if strings.Contains(output, " all ") {
+		t.Errorf("expected the filter to exclude the classic queue, got:\n%s", output)
+	}
+}
+
+func TestGetQueuesOnMultiplePages(t *testing.T) {
+	c := newCLITest(t)
+
+	for i := 0; i < maxPageSize+10; i++ {
+		if _, err := c.server("localhost").CreateQueue(Queue{Name: fmt.Sprintf("queue-%04d", i)}); err != nil {
+			t.Fatalf("creating queue: %v", err)
+		}
+	}
+
+	output := c.mustRun("get", "queues", "localhost")
+
+	if count := strings.Count(output, "EFFECTIVE POLICY"); count != 1 {
+		t.Errorf("expected a single table header, got %d", count)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+
+	if len(lines) != maxPageSize+14 {
+		t.Errorf("expected %d lines, got %d", maxPageSize+14, len(lines))
+	}
+
+	// The rows of all pages have to line up with the header.
+	for _, line := range lines {
+		if len(line) != len(lines[0]) {
+			t.Errorf("expected all lines to be %d characters wide, got %q", len(lines[0]), line)
+			break
+		}
+	}
+
+	expectOutput(t, output, "queue-0000", fmt.Sprintf("queue-%04d", maxPageSize+9))
+}
+
+func TestPublishAndGetMessages(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	expectOutput(t, c.mustRun("publish", "localhost", "orders", "order.created", "first"),
+		"message published successfully")
+	c.mustRun("publish", "localhost", "orders", "order.deleted", "second", "--headers", "region=eu")
+
+	counts := messageCounts(t, c.server("localhost"))
+
+	if counts["created"] != 1 || counts["all"] != 2 {
+		t.Fatalf("expected 1 message in created and 2 messages in all, got %v", counts)
+	}
+
+	expectOutput(t, c.mustRun("get", "messages", "localhost", "all", "--max", "2", "--requeue", "--force"),
+		"first", "second")
+
+	if count := messageCounts(t, c.server("localhost"))["all"]; count != 2 {
+		t.Fatalf("expected --requeue to keep the messages, got %d messages", count)
+	}
+
+	expectOutput(t, c.mustRun("get", "messages", "localhost", "all", "--max", "1", "--force"), "first")
+
+	if count := messageCounts(t, c.server("localhost"))["all"]; count != 1 {
+		t.Fatalf("expected one message to be de-queued, got %d messages", count)
+	}
+
+	if _, err := c.run("publish", "localhost", "missing", "key", "body"); err == nil {
+		t.Error("expected publishing to a missing exchange to fail")
+	}
+}
+
+func TestPurgeQueue(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	c.mustRun("publish", "localhost", "orders", "order.created", "body")
+
+	expectOutput(t, c.mustRun("purge", "queue", "localhost", "created", "--force"), "queue purged successfully")
+
+	if count := messageCounts(t, c.server("localhost"))["created"]; count != 0 {
+		t.Fatalf("expected the queue to be empty, got %d messages", count)
+	}
+}
+
+func TestDeleteCommands(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	c.mustRun("publish", "localhost", "orders", "order.created", "body")
+
+	if _, err := c.run("delete", "queue", "localhost", "created", "--if-empty", "--force"); err == nil {
+		t.Error("expected deleting a queue with messages to fail with --if-empty")
+	}
+
+	if _, err := c.run("delete", "exchange", "localhost", "orders", "--if-unused", "--force"); err == nil {
+		t.Error("expected deleting an exchange with bindings to fail with --if-unused")
+	}
+
+	expectOutput(t, c.mustRun("delete", "queue", "localhost", "created", "--force"), "queue deleted successfully")
+	expectOutput(t, c.mustRun("delete", "exchange", "localhost", "orders", "--force"), "exchange deleted successfully")
+	expectOutput(t, c.mustRun("delete", "user", "localhost", "alice"), "user deleted successfully")
+	expectOutput(t, c.mustRun("delete", "policy", "localhost", "ha"), "policy deleted successfully")
+
+	c.mustRun("create", "vhost", "localhost", "staging")
+	expectOutput(t, c.mustRun("delete", "vhost", "localhost", "staging", "--force"), "vhost deleted successfully")
+
+	exchanges, _ := c.server("localhost").GetExchanges(func(exchange Exchange) bool {
+		return exchange.Name == "orders"
+	})
+	queues, _ := c.server("localhost").GetQueues(func(queue Queue) bool {
+		return queue.Name == "created"
+	})
+	users, _ := c.server("localhost").GetUsers(func(_ User) bool {
+		return true
+	})
+	vhosts, _ := c.server("localhost").GetVhosts(func(vhost Vhost) bool {
+		return vhost.Name == "staging"
+	})
+
+	if len(exchanges) != 0 || len(queues) != 0 || len(users) != 0 || len(vhosts) != 0 {
+		t.Errorf("expected all resources to be deleted, got %v, %v, %v, %v", exchanges, queues, users, vhosts)
+	}
+}
+
+func TestDeleteQueueConfirmation(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	c.mustRun("create", "queue", "localhost", "created-dlq", "classic")
+	c.mustRun("publish", "localhost", "orders", "order.created", "body")
+
+	answer(t, "n")
+	expectOutput(t, c.mustRun("delete", "queue", "localhost", "created"),
+		"delete the queue created with 1 messages and 0 consumers?")
+
+	if counts := messageCounts(t, c.server("localhost")); len(counts) != 3 {
+		t.Fatalf("expected declining not to delete the queue, got %v", counts)
+	}
+
+	if _, err := c.run("delete", "queue", "localhost", "create"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleting a missing queue to fail with ErrNotFound, got %v", err)
+	}
+
+	// The queue is looked up by its name on the server instead of reading all queues.
+	api := newFakeAPI(t)
+
+	answer(t, "n")
+
+	options := globalOptions{
+		out: &strings.Builder{},
+		providerFactory: func(config *RabbitMQConfig) Provider {
+			return api.provider(config.Vhost)
+		},
+	}
+
+	root := rootCommand(&options)
+	root.SetArgs([]string{"delete", "queue", "localhost", "orders-eu", "--user", fakeAPIUser, "--password", fakeAPIPassword})
+
+	if err := root.Execute(); err != nil {
+		t.Fatalf("running delete queue: %v", err)
+	}
+
+	requests := api.received()
+
+	if len(requests) != 1 || !strings.HasPrefix(requests[0].String(), "GET /api/queues/%2F?") || !strings.Contains(requests[0].uri, "name=orders-eu") {
+		t.Errorf("expected a single request filtering queues by name, got %q", requests)
+	}
+}
+
+// answer lets confirmation prompts read the given answer from os.Stdin until the test
+// finishes.
+func answer(t *testing.T, input string) {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("creating pipe: %v", err)
+	}
+
+	_, _ = writer.WriteString(input + "\n")
+	_ = writer.Close()
+
+	stdin := os.Stdin
+	os.Stdin = reader
+
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		_ = reader.Close()
+	})
+}
+
+func TestBulkDelete(t *testing.T) {
+	c := newCLITest(t)
+
+	for _, name := range []string{"it-1", "it-2", "it-3", "keep"} {
+		c.mustRun("create", "queue", "localhost", name, "classic")
+	}
+
+	c.mustRun("publish", "localhost", "", "it-3", "body")
+
+	output := c.mustRun("delete", "queue", "localhost", "--prefix", "it-", "--if-empty", "--force")
+	expectOutput(t, output, "it-1 (0 messages)", "it-2 (0 messages)", "2 queues deleted successfully")
+
+	if strings.Contains(output, "it-3") {
+		t.Errorf("expected it-3 not to be selected with --if-empty, got:\n%s", output)
+	}
+
+	counts := messageCounts(t, c.server("localhost"))
+
+	if len(counts) != 2 || counts["it-3"] != 1 {
+		t.Fatalf("expected it-3 and keep to remain, got %v", counts)
+	}
+
+	expectOutput(t, c.mustRun("delete", "queue", "localhost", "--regex", "^nothing$", "--force"), "no queues matched")
+
+	if _, err := c.run("delete", "queue", "localhost", "keep", "--prefix", "it-"); err == nil {
+		t.Error("expected combining a name with --prefix to fail")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	testCases := map[string]struct {
+		args     []string
+		expected []string
+	}{
+		"create exchange":       {args: []string{"create", "exchange", "localhost", "payments", "direct"}, expected: []string{"PUT /api/exchanges/%2F/payments", `"type": "direct"`}},
+		"create queue":          {args: []string{"create", "queue", "localhost", "payments", "classic"}, expected: []string{"PUT /api/queues/%2F/payments"}},
+		"delete exchange":       {args: []string{"delete", "exchange", "localhost", "orders", "--force"}, expected: []string{"DELETE /api/exchanges/%2F/orders"}},
+		"delete queue":          {args: []string{"delete", "queue", "localhost", "created", "--if-empty", "--force"}, expected: []string{"DELETE /api/queues/%2F/created?if-empty=true"}},
+		"bulk delete":           {args: []string{"delete", "queue", "localhost", "--prefix", "c", "--force"}, expected: []string{"DELETE /api/queues/%2F/created"}},
+		"bulk delete if unused": {args: []string{"delete", "queue", "localhost", "--prefix", "c", "--if-unused", "--force"}, expected: []string{"DELETE /api/queues/%2F/created?if-unused=true"}},
+		"publish":               {args: []string{"publish", "localhost", "orders", "order.created", "body"}, expected: []string{"AMQP basic.publish", `"routing_key": "order.created"`}},
+		"purge queue":           {args: []string{"purge", "queue", "localhost", "all", "--force"}, expected: []string{"DELETE /api/queues/%2F/all/contents"}},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			output := c.mustRun(append(testCase.args, "--dry-run")...)
+			expectOutput(t, output, append(testCase.expected, "dry run: nothing was changed")...)
+
+			if strings.Contains(output, "successfully") {
+				t.Errorf("expected no success message with --dry-run, got:\n%s", output)
+			}
+		})
+	}
+
+	c.mustRun("publish", "localhost", "orders", "order.created", "body")
+
+	if counts := messageCounts(t, c.server("localhost")); len(counts) != 2 || counts["created"] != 1 || counts["all"] != 1 {
+		t.Errorf("expected --dry-run not to change any queues, got %v", counts)
+	}
+
+	exchanges, _ := c.server("localhost").GetExchanges(func(exchange Exchange) bool {
+		return exchange.Name == "orders" || exchange.Name == "payments"
+	})
+
+	if len(exchanges) != 1 || exchanges[0].Name != "orders" {
+		t.Errorf("expected --dry-run not to change any exchanges, got %v", exchanges)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	server := c.server("localhost")
+
+	if err := server.CreateExchange(Exchange{Name: "unrouted", Type: Fanout}); err != nil {
+		t.Fatalf("creating exchange: %v", err)
+	}
+
+	if err := server.CreateExchange(Exchange{Name: "payments", Type: Direct, Arguments: map[string]interface{}{"alternate-exchange": "unrouted"}}); err != nil {
+		t.Fatalf("creating exchange: %v", err)
+	}
+
+	c.mustRun("create", "binding", "localhost", "unrouted", "all", "")
+
+	c.configs = nil
+	output := c.mustRun("route", "localhost", "payments", "payment.created")
+
+	expectOutput(t, output, "payments -> (alternate)", "unrouted -> [] all")
+
+	if len(c.configs) != 1 || c.configs[0].Vhost != "/" {
+		t.Errorf("expected route to use the default virtual host, got %+v", c.configs)
+	}
+}
+
+func TestGraph(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	c.configs = nil
+	expectOutput(t, c.mustRun("graph", "localhost", "--from", "orders"), "orders", "created", "all")
+
+	if len(c.configs) != 1 || c.configs[0].Vhost != "/" {
+		t.Errorf("expected graph to use the default virtual host, got %+v", c.configs)
+	}
+}
+
+func TestCloseConnection(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	// The queue `all` has a consumer, which is cancelled when closing the connection.
+	expectOutput(t, c.mustRun("get", "queue", "localhost", "all"), " 1 |")
+
+	expectOutput(t, c.mustRun("close", "connection", "localhost", seedConnection, "--reason", "maintenance"),
+		"connection closed successfully")
+
+	output := c.mustRun("get", "connections", "localhost") + c.mustRun("get", "consumers", "localhost")
+
+	if strings.Contains(output, "alice") || strings.Contains(output, "ctag-billing") {
+		t.Errorf("expected the connection and its consumers to be closed, got:\n%s", output)
+	}
+
+	if _, err := c.run("close", "connection", "localhost", seedConnection); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected closing a missing connection to fail with ErrNotFound, got %v", err)
+	}
+}
+
+func TestDefinitionsImport(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	output := c.mustRun("definitions", "export", "localhost")
+
+	file, err := ioutil.TempFile("", "buneary-definitions-*.json")
+	if err != nil {
+		t.Fatalf("creating definitions file: %v", err)
+	}
+
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	if _, err := file.WriteString(output); err != nil {
+		t.Fatalf("writing definitions file: %v", err)
+	}
+	_ = file.Close()
+
+	expectOutput(t, c.mustRun("definitions", "import", "other", file.Name(), "--dry-run"), "orders")
+
+	if queues, _ := c.server("other").GetQueues(func(_ Queue) bool { return true }); len(queues) != 0 {
+		t.Fatalf("expected --dry-run not to import anything, got %v", queues)
+	}
+
+	expectOutput(t, c.mustRun("definitions", "import", "other", file.Name()), "definitions imported successfully")
+
+	c.mustRun("publish", "other", "orders", "order.created", "body")
+
+	if count := messageCounts(t, c.server("other"))["created"]; count != 1 {
+		t.Fatalf("expected the imported topology to route the message, got %d messages", count)
+	}
+
+	// The definitions of the entire server contain users and `vhost` fields, which the
+	// definitions of a single virtual host don't.
+	expectOutput(t, c.mustRun("definitions", "import", "other", file.Name(), "--vhost", "/", "--dry-run"),
+		"0 definitions would be added")
+
+	if _, err := c.run("definitions", "import", "other", filepath.Join(os.TempDir(), "buneary-missing.json")); err == nil {
+		t.Error("expected importing a missing file to fail")
+	}
+}
+
+func TestClone(t *testing.T) {
+	c := newCLITest(t)
+	c.seed()
+
+	expectOutput(t, c.mustRun("clone", "localhost", "other", "--dry-run"), "orders", "created")
+
+	if queues, _ := c.server("other").GetQueues(func(_ Queue) bool { return true }); len(queues) != 0 {
+		t.Fatalf("expected --dry-run not to declare anything, got %v", queues)
+	}
+
+	expectOutput(t, c.mustRun("clone", "localhost", "other"), "cloned 1 exchanges, 2 queues and 2 bindings successfully")
+
+	c.mustRun("publish", "other", "orders", "order.created", "body")
+
+	if count := messageCounts(t, c.server("other"))["created"]; count != 1 {
+		t.Fatalf("expected the cloned topology to route the message, got %d messages", count)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Definitions represents the server definitions in RabbitMQ's native JSON format, as
+// returned and accepted by the /api/definitions endpoint of the RabbitMQ HTTP API.
+//
+// Definitions exported for a single virtual host don't contain users, virtual hosts
+// and permissions, and the contained resources don't have a `vhost` field.
+type Definitions struct {
+	RabbitVersion    string       `json:"rabbit_version,omitempty"`
+	Users            []Definition `json:"users,omitempty"`
+	Vhosts           []Definition `json:"vhosts,omitempty"`
+	Permissions      []Definition `json:"permissions,omitempty"`
+	TopicPermissions []Definition `json:"topic_permissions,omitempty"`
+	Parameters       []Definition `json:"parameters,omitempty"`
+	GlobalParameters []Definition `json:"global_parameters,omitempty"`
+	Policies         []Definition `json:"policies,omitempty"`
+	Queues           []Definition `json:"queues,omitempty"`
+	Exchanges        []Definition `json:"exchanges,omitempty"`
+	Bindings         []Definition `json:"bindings,omitempty"`
+}
+
+// Definition is a single resource definition like a user or a queue. The available
+// fields depend on the resource kind and RabbitMQ version, so they're kept as they
+// are in order to not lose any information when importing the definition again.
+type Definition map[string]interface{}
+
+// definitionKinds lists all resource kinds within Definitions along with the fields
+// that identify a resource of that kind. Two definitions of the same kind denote the
+// same resource if all of their identifying fields are equal. Resource kinds that are
+// part of the definitions of a single virtual host are marked as perVhost.
+var definitionKinds = []struct {
+	name     string
+	fields   []string
+	perVhost bool
+}{
+	{"users", []string{"name"}, false},
+	{"vhosts", []string{"name"}, false},
+	{"permissions", []string{"user", "vhost"}, false},
+	{"topic_permissions", []string{"user", "vhost", "exchange"}, false},
+	{"parameters", []string{"component", "vhost", "name"}, true},
+	{"global_parameters", []string{"name"}, false},
+	{"policies", []string{"vhost", "name"}, true},
+	{"queues", []string{"vhost", "name"}, true},
+	{"exchanges", []string{"vhost", "name"}, true},
+	{"bindings", []string{"vhost", "source", "destination", "destination_type", "routing_key", "arguments"}, true},
+}
+
+// kinds returns a pointer to each resource list within the definitions, keyed by the
+// name of the resource kind as listed in definitionKinds.
+func (d *Definitions) kinds() map[string]*[]Definition {
+	return map[string]*[]Definition{
+		"users":             &d.Users,
+		"vhosts":            &d.Vhosts,
+		"permissions":       &d.Permissions,
+		"topic_permissions": &d.TopicPermissions,
+		"parameters":        &d.Parameters,
+		"global_parameters": &d.GlobalParameters,
+		"policies":          &d.Policies,
+		"queues":            &d.Queues,
+		"exchanges":         &d.Exchanges,
+		"bindings":          &d.Bindings,
+	}
+}
+
+// Filter removes all resources from the definitions whose kind isn't contained in the
+// given list of kinds, for example `queues` or `exchanges`. If the list is empty, the
+// definitions will remain unchanged.
+func (d *Definitions) Filter(kinds []string) error {
+	if len(kinds) == 0 {
+		return nil
+	}
+
+	include := make(map[string]bool)
+
+	for _, kind := range kinds {
+		if _, ok := d.kinds()[kind]; !ok {
+			return fmt.Errorf("unknown resource kind %q, expected one of %s", kind, strings.Join(definitionKindNames(), ", "))
+		}
+		include[kind] = true
+	}
+
+	for kind, resources := range d.kinds() {
+		if !include[kind] {
+			*resources = nil
+		}
+	}
+
+	return nil
+}
+
+// DefinitionChange is a single resource that would be added by importing definitions.
+type DefinitionChange struct {
+	Kind       string
+	Identifier string
+}
+
+// Additions returns all resources in the given definitions that don't exist in the
+// current definitions d yet. Resources that exist in both definitions are considered
+// to be equal if their identifying fields are equal, even if their settings differ.
+//
+// If a virtual host is given, d are the definitions of that virtual host and all given
+// definitions would be imported into it. In that case, the `vhost` field is ignored,
+// since the current definitions don't contain it, and resources that don't belong to
+// a virtual host like users are skipped, since they wouldn't be imported.
+func (d *Definitions) Additions(desired *Definitions, vhost string) []DefinitionChange {
+	var (
+		current = d.kinds()
+		target  = desired.kinds()
+		changes []DefinitionChange
+	)
+
+	for _, kind := range definitionKinds {
+		if vhost != "" && !kind.perVhost {
+			continue
+		}
+
+		fields := kind.fields
+
+		if vhost != "" {
+			fields = withoutField(fields, "vhost")
+		}
+
+		existing := make(map[string]bool)
+
+		for _, definition := range *current[kind.name] {
+			existing[definition.identifier(fields)] = true
+		}
+
+		for _, definition := range *target[kind.name] {
+			identifier := definition.identifier(fields)
+			if existing[identifier] {
+				continue
+			}
+			changes = append(changes, DefinitionChange{
+				Kind:       kind.name,
+				Identifier: identifier,
+			})
+		}
+	}
+
+	return changes
+}
+
+// identifier builds a human-readable identifier for the definition from the given
+// fields. Fields that don't exist in the definition are skipped.
+func (d Definition) identifier(fields []string) string {
+	var tokens []string
+
+	for _, field := range fields {
+		value, ok := d[field]
+		if !ok {
+			continue
+		}
+		tokens = append(tokens, fmt.Sprintf("%s=%v", field, value))
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// withoutField returns the given fields without the given field.
+func withoutField(fields []string, field string) []string {
+	var result []string
+
+	for _, f := range fields {
+		if f != field {
+			result = append(result, f)
+		}
+	}
+
+	return result
+}
+
+// definitionKindNames returns the sorted names of all resource kinds.
+func definitionKindNames() []string {
+	names := make([]string, len(definitionKinds))
+
+	for i, kind := range definitionKinds {
+		names[i] = kind.name
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// definitionsPath returns the RabbitMQ HTTP API path for the definitions of the given
+// virtual host, or for the definitions of the entire server if vhost is empty.
+func definitionsPath(vhost string) string {
+	if vhost == "" {
+		return "/api/definitions"
+	}
+	return "/api/definitions/" + url.PathEscape(vhost)
+}
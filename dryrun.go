@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// NewDryRunProvider returns a Provider that doesn't change anything on the server.
+// Instead, all mutating operations print the HTTP requests or AMQP operations that
+// would be sent to out. Read operations are executed as usual.
+func NewDryRunProvider(config *RabbitMQConfig, out io.StringWriter) Provider {
+	return newDryRunProvider(NewProvider(config), config, out)
+}
+
+// newDryRunProvider returns a dry-run Provider that passes read operations to the
+// given Provider. The printed requests are built for the given configuration.
+func newDryRunProvider(provider Provider, config *RabbitMQConfig, out io.StringWriter) Provider {
+	recorder := &recordingTransport{
+		out: out,
+	}
+
+	d := dryRunProvider{
+		Provider: provider,
+		recorder: &buneary{
+			config:    config,
+			transport: recorder,
+		},
+		transport: recorder,
+	}
+
+	return &d
+}
+
+// dryRunProvider is a Provider decorator that passes read operations to the decorated
+// Provider, while mutating operations are only recorded.
+//
+// Operations using the HTTP API are run against a buneary instance whose transport
+// prints the requests instead of sending them, so that the printed requests are the
+// exact requests the regular implementation would send. AMQP operations are printed
+// by dryRunProvider itself.
+type dryRunProvider struct {
+	Provider
+	recorder  *buneary
+	transport *recordingTransport
+}
+
+// CreateExchange prints the request for creating the given exchange.
+func (d *dryRunProvider) CreateExchange(exchange Exchange) error {
+	return d.recorder.CreateExchange(exchange)
+}
+
+// CreateQueue prints the request for creating the given queue. Since server-named
+// queues are declared via AMQP, the declaration is printed and `(server-named)` is
+// returned as queue name for these queues.
+func (d *dryRunProvider) CreateQueue(queue Queue) (string, error) {
+	if queue.Name != "" {
+		return d.recorder.CreateQueue(queue)
+	}
+
+	args := make(map[string]interface{})
+
+	for key, value := range queue.Arguments {
+		args[key] = value
+	}
+
+	if queue.Type != "" {
+		args["x-queue-type"] = string(queue.Type)
+	}
+
+	d.transport.record("AMQP queue.declare", map[string]interface{}{
+		"queue":       "",
+		"durable":     queue.Durable,
+		"auto_delete": queue.AutoDelete,
+		"exclusive":   false,
+		"no_wait":     false,
+		"arguments":   args,
+	})
+
+	return "(server-named)", nil
+}
+
+// CreateBinding prints the request for creating the given binding.
+func (d *dryRunProvider) CreateBinding(binding Binding) error {
+	return d.recorder.CreateBinding(binding)
+}
+
+// CreateUser prints the request for creating the given user.
+func (d *dryRunProvider) CreateUser(user User) error {
+	return d.recorder.CreateUser(user)
+}
+
+// CreateVhost prints the request for creating the given virtual host.
+func (d *dryRunProvider) CreateVhost(vhost Vhost) error {
+	return d.recorder.CreateVhost(vhost)
+}
+
+// CreatePolicy prints the request for creating the given policy.
+func (d *dryRunProvider) CreatePolicy(policy Policy) error {
+	return d.recorder.CreatePolicy(policy)
+}
+
+// PublishMessage prints the AMQP operation for publishing the given message.
+func (d *dryRunProvider) PublishMessage(message Message) error {
+	exchange, routingKey, mandatory, immediate, publishing := messageArgs(message)
+
+	d.transport.record("AMQP basic.publish", map[string]interface{}{
+		"exchange":    exchange,
+		"routing_key": routingKey,
+		"mandatory":   mandatory,
+		"immediate":   immediate,
+		"properties":  publishingProperties(publishing),
+		"body":        string(publishing.Body),
+	})
+
+	return nil
+}
+
+// GetMessages reads messages from the given queue if they're re-queued. Otherwise,
+// reading them would remove them from the queue, so only the request is printed and
+// no messages are returned.
+func (d *dryRunProvider) GetMessages(queue Queue, max int, requeue bool) ([]Message, error) {
+	if requeue {
+		return d.Provider.GetMessages(queue, max, requeue)
+	}
+	return d.recorder.GetMessages(queue, max, requeue)
+}
+
+// ImportDefinitions prints the request for importing the given definitions.
+func (d *dryRunProvider) ImportDefinitions(vhost string, definitions *Definitions) error {
+	return d.recorder.ImportDefinitions(vhost, definitions)
+}
+
+// SetPermissions prints the request for setting the given permissions.
+func (d *dryRunProvider) SetPermissions(permissions Permissions) error {
+	return d.recorder.SetPermissions(permissions)
+}
+
+// DeleteExchange prints the request for deleting the given exchange.
+func (d *dryRunProvider) DeleteExchange(exchange Exchange, conditions DeleteConditions) error {
+	return d.recorder.DeleteExchange(exchange, conditions)
+}
+
+// DeleteQueue prints the request for deleting the given queue.
+func (d *dryRunProvider) DeleteQueue(queue Queue, conditions DeleteConditions) error {
+	return d.recorder.DeleteQueue(queue, conditions)
+}
+
+// PurgeQueue prints the request for purging the given queue.
+func (d *dryRunProvider) PurgeQueue(queue Queue) error {
+	return d.recorder.PurgeQueue(queue)
+}
+
+// DeleteUser prints the request for deleting the given user.
+func (d *dryRunProvider) DeleteUser(user User) error {
+	return d.recorder.DeleteUser(user)
+}
+
+// DeleteVhost prints the request for deleting the given virtual host.
+func (d *dryRunProvider) DeleteVhost(vhost Vhost) error {
+	return d.recorder.DeleteVhost(vhost)
+}
+
+// DeletePolicy prints the request for deleting the given policy.
+func (d *dryRunProvider) DeletePolicy(policy Policy) error {
+	return d.recorder.DeletePolicy(policy)
+}
+
+// CloseConnection prints the request for closing the given connection.
+func (d *dryRunProvider) CloseConnection(connection Connection, reason string) error {
+	return d.recorder.CloseConnection(connection, reason)
+}
+
+// recordingTransport is an http.RoundTripper that prints all requests instead of
+// sending them to the server. Each request is answered with 204 No Content.
+type recordingTransport struct {
+	out   io.StringWriter
+	mutex sync.Mutex
+}
+
+// RoundTrip prints the method, path, custom headers and body of the given request.
+// JSON bodies are indented for readability.
+func (r *recordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if request.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(request.Body); err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+		_ = request.Body.Close()
+	}
+
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("%s %s\n", request.Method, request.URL.RequestURI()))
+
+	// Only custom headers like X-Reason are printed. Authorization and content type
+	// headers are the same for all requests.
+	var keys []string
+
+	for key := range request.Header {
+		if strings.HasPrefix(key, "X-") {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		output.WriteString(fmt.Sprintf("%s: %s\n", key, request.Header.Get(key)))
+	}
+
+	if len(body) > 0 {
+		var indented bytes.Buffer
+
+		if err := json.Indent(&indented, body, "", "  "); err != nil {
+			output.Write(body)
+		} else {
+			output.Write(indented.Bytes())
+		}
+
+		output.WriteString("\n")
+	}
+
+	r.write(output.String())
+
+	response := http.Response{
+		Status:     "204 No Content",
+		StatusCode: http.StatusNoContent,
+		Proto:      request.Proto,
+		ProtoMajor: request.ProtoMajor,
+		ProtoMinor: request.ProtoMinor,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		Request:    request,
+	}
+
+	return &response, nil
+}
+
+// record prints the given operation, followed by its arguments as indented JSON.
+func (r *recordingTransport) record(operation string, arguments map[string]interface{}) {
+	output := operation + "\n"
+
+	if encoded, err := json.MarshalIndent(arguments, "", "  "); err == nil {
+		output += string(encoded) + "\n"
+	}
+
+	r.write(output)
+}
+
+// write prints the given output, followed by an empty line. Since resources may be
+// deleted concurrently, the output is synchronized to prevent interleaved requests.
+func (r *recordingTransport) write(output string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, _ = r.out.WriteString(output + "\n")
+}
+
+// publishingProperties returns all message properties of the given publishing that
+// have been set, using the property names of the AMQP specification.
+func publishingProperties(publishing amqp.Publishing) map[string]interface{} {
+	properties := make(map[string]interface{})
+
+	stringProperties := map[string]string{
+		"content_type":     publishing.ContentType,
+		"content_encoding": publishing.ContentEncoding,
+		"correlation_id":   publishing.CorrelationId,
+		"reply_to":         publishing.ReplyTo,
+		"expiration":       publishing.Expiration,
+		"message_id":       publishing.MessageId,
+		"type":             publishing.Type,
+		"user_id":          publishing.UserId,
+		"app_id":           publishing.AppId,
+	}
+
+	for name, value := range stringProperties {
+		if value != "" {
+			properties[name] = value
+		}
+	}
+
+	if len(publishing.Headers) > 0 {
+		properties["headers"] = publishing.Headers
+	}
+
+	if publishing.DeliveryMode != 0 {
+		properties["delivery_mode"] = publishing.DeliveryMode
+	}
+
+	if publishing.Priority != 0 {
+		properties["priority"] = publishing.Priority
+	}
+
+	if !publishing.Timestamp.IsZero() {
+		properties["timestamp"] = publishing.Timestamp.Format(time.RFC3339)
+	}
+
+	return properties
+}
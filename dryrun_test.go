@@ -0,0 +1,180 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestDryRunProvider_Requests verifies that mutating operations print the request that
+// would be sent, without sending any mutating request to the fake API.
+func TestDryRunProvider_Requests(t *testing.T) {
+	testCases := []struct {
+		name string
+		call func(provider Provider) error
+		// operation is the first printed line, i.e. the request or AMQP operation.
+		operation string
+		// body holds fields expected in the printed body, if any.
+		body object
+	}{
+		{
+			name: "create exchange",
+			call: func(provider Provider) error {
+				return provider.CreateExchange(Exchange{Name: "orders", Type: Topic, Durable: true})
+			},
+			operation: "PUT /api/exchanges/%2F/orders",
+			body:      object{"type": "topic", "durable": true},
+		},
+		{
+			name: "create queue",
+			call: func(provider Provider) error {
+				_, err := provider.CreateQueue(Queue{Name: "orders-eu", Type: Quorum, Durable: true})
+				return err
+			},
+			operation: "PUT /api/queues/%2F/orders-eu",
+			body:      object{"type": "quorum", "durable": true},
+		},
+		{
+			name: "create server-named queue",
+			call: func(provider Provider) error {
+				_, err := provider.CreateQueue(Queue{Type: Classic})
+				return err
+			},
+			operation: "AMQP queue.declare",
+			body:      object{"queue": "", "arguments": object{"x-queue-type": "classic"}},
+		},
+		{
+			name: "delete exchange",
+			call: func(provider Provider) error {
+				return provider.DeleteExchange(Exchange{Name: "orders"}, DeleteConditions{IfUnused: true})
+			},
+			operation: "DELETE /api/exchanges/%2F/orders?if-unused=true",
+		},
+		{
+			name: "delete queue",
+			call: func(provider Provider) error {
+				return provider.DeleteQueue(Queue{Name: "orders-eu"}, DeleteConditions{IfEmpty: true})
+			},
+			operation: "DELETE /api/queues/%2F/orders-eu?if-empty=true",
+		},
+		{
+			name: "publish message",
+			call: func(provider Provider) error {
+				return provider.PublishMessage(Message{
+					Target:     Exchange{Name: "orders"},
+					RoutingKey: "eu.de",
+					Body:       []byte("hello"),
+				})
+			},
+			operation: "AMQP basic.publish",
+			body:      object{"exchange": "orders", "routing_key": "eu.de", "body": "hello"},
+		},
+		{
+			name: "purge queue",
+			call: func(provider Provider) error {
+				return provider.PurgeQueue(Queue{Name: "orders-eu"})
+			},
+			operation: "DELETE /api/queues/%2F/orders-eu/contents",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			api := newFakeAPI(t)
+
+			var output strings.Builder
+
+			provider := NewDryRunProvider(api.config("/", fakeAPIUser, fakeAPIPassword), &output)
+
+			if err := testCase.call(provider); err != nil {
+				t.Fatalf("calling provider: %v", err)
+			}
+
+			for _, request := range api.received() {
+				if request.method != "GET" {
+					t.Errorf("expected no mutating requests to reach the server, got %s", request)
+				}
+			}
+
+			lines := strings.SplitN(strings.TrimSpace(output.String()), "\n", 2)
+
+			if lines[0] != testCase.operation {
+				t.Errorf("expected %q to be printed, got:\n%s", testCase.operation, output.String())
+			}
+
+			if testCase.body == nil {
+				if len(lines) > 1 {
+					t.Errorf("expected no body to be printed, got:\n%s", output.String())
+				}
+				return
+			}
+
+			if len(lines) < 2 {
+				t.Fatalf("expected a body to be printed, got:\n%s", output.String())
+			}
+
+			expectBody(t, []byte(lines[1]), testCase.body)
+		})
+	}
+}
+
+func TestDryRunProvider_GetMessages(t *testing.T) {
+	testCases := []struct {
+		name    string
+		requeue bool
+		// messages is the number of messages expected to be returned.
+		messages int
+		// requests holds the requests expected to reach the server.
+		requests []string
+		// output holds strings expected in the printed requests.
+		output []string
+	}{
+		{
+			name:     "requeue",
+			requeue:  true,
+			messages: 2,
+			requests: []string{"POST /api/queues/%2F/orders-eu/get"},
+		},
+		{
+			name:     "no requeue",
+			requeue:  false,
+			messages: 0,
+			output:   []string{"POST /api/queues/%2F/orders-eu/get", `"ackmode": "ack_requeue_false"`},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			api := newFakeAPI(t)
+
+			var output strings.Builder
+
+			provider := NewDryRunProvider(api.config("/", fakeAPIUser, fakeAPIPassword), &output)
+
+			messages, err := provider.GetMessages(Queue{Name: "orders-eu"}, 2, testCase.requeue)
+			if err != nil {
+				t.Fatalf("reading messages: %v", err)
+			}
+
+			if len(messages) != testCase.messages {
+				t.Errorf("expected %d messages, got %d", testCase.messages, len(messages))
+			}
+
+			var requests []string
+
+			for _, request := range api.received() {
+				requests = append(requests, request.String())
+			}
+
+			if !reflect.DeepEqual(requests, testCase.requests) {
+				t.Errorf("expected requests %q, got %q", testCase.requests, requests)
+			}
+
+			expectOutput(t, output.String(), testCase.output...)
+
+			if len(testCase.output) == 0 && output.Len() > 0 {
+				t.Errorf("expected no printed requests, got:\n%s", output.String())
+			}
+		})
+	}
+}
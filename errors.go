@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/streadway/amqp"
+)
+
+var (
+	// ErrNotFound indicates that a resource like an exchange or a queue doesn't exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized indicates that the credentials are invalid or that the user
+	// isn't allowed to perform the operation.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrPreconditionFailed indicates that the server refused an operation because of
+	// the state of a resource, e.g. because an exchange exists with a different type
+	// or because a queue to be deleted isn't empty.
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrUnreachable indicates that the server couldn't be reached or has closed the
+	// connection.
+	ErrUnreachable = errors.New("server unreachable")
+)
+
+// ServerError is an error returned by the RabbitMQ server or caused by the connection
+// to it. Errors that fall into a known category can be matched with errors.Is using
+// ErrNotFound, ErrUnauthorized, ErrPreconditionFailed or ErrUnreachable.
+type ServerError struct {
+
+	// Kind is the category of the error, or nil if the error doesn't fall into one.
+	Kind error
+
+	// Code is the HTTP status code or AMQP reply code returned by the server. It is 0
+	// if the server couldn't be reached.
+	Code int
+
+	// Reason is the reason given by the server, e.g. `inequivalent arg 'type' for
+	// exchange 'orders' in vhost '/': received 'fanout' but current is 'topic'`.
+	Reason string
+
+	// Err is the underlying error. If it is nil, Reason is used as error message.
+	Err error
+}
+
+// Error returns the message of the underlying error or the reason.
+func (s *ServerError) Error() string {
+	if s.Err == nil {
+		return s.Reason
+	}
+	return s.Err.Error()
+}
+
+// Is reports whether the error falls into the category of the given error.
+func (s *ServerError) Is(target error) bool {
+	return s.Kind != nil && s.Kind == target
+}
+
+// Unwrap returns the underlying error, e.g. an *amqp.Error.
+func (s *ServerError) Unwrap() error {
+	return s.Err
+}
+
+// serverError converts errors returned by rabbit-hole, the AMQP library or the HTTP
+// client into a *ServerError. Other errors and errors that already are a *ServerError
+// are returned as they are.
+func serverError(err error) error {
+	var (
+		existing      *ServerError
+		errorResponse rabbithole.ErrorResponse
+		amqpError     *amqp.Error
+		netError      net.Error
+	)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &existing):
+		return err
+	case errors.As(err, &errorResponse):
+		return &ServerError{
+			Kind:   httpErrorKind(errorResponse.StatusCode, errorResponse.Reason),
+			Code:   errorResponse.StatusCode,
+			Reason: errorResponse.Reason,
+			Err:    err,
+		}
+	// rabbit-hole doesn't return an ErrorResponse for 401 Unauthorized, so this
+	// error can only be recognized by its message.
+	case strings.Contains(err.Error(), "401 Unauthorized"):
+		return &ServerError{
+			Kind: ErrUnauthorized,
+			Code: http.StatusUnauthorized,
+			Err:  err,
+		}
+	case errors.As(err, &amqpError):
+		return &ServerError{
+			Kind:   amqpErrorKind(amqpError.Code),
+			Code:   amqpError.Code,
+			Reason: amqpError.Reason,
+			Err:    err,
+		}
+	case errors.As(err, &netError), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return &ServerError{
+			Kind: ErrUnreachable,
+			Err:  err,
+		}
+	}
+
+	return err
+}
+
+// httpErrorKind returns the category for the given status code and reason returned
+// by the RabbitMQ HTTP API. The HTTP API reports most refused operations with 400 Bad
+// Request, so they can only be recognized by their reason.
+func httpErrorKind(statusCode int, reason string) error {
+	switch statusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return ErrPreconditionFailed
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrUnreachable
+	case http.StatusBadRequest:
+		reason = strings.ToLower(reason)
+		for _, s := range []string{"inequivalent arg", "in use", "not empty", "precondition_failed"} {
+			if strings.Contains(reason, s) {
+				return ErrPreconditionFailed
+			}
+		}
+	}
+
+	return nil
+}
+
+// amqpErrorKind returns the category for the given AMQP reply code.
+func amqpErrorKind(code int) error {
+	switch code {
+	case amqp.NotFound:
+		return ErrNotFound
+	case amqp.AccessRefused, amqp.NotAllowed:
+		return ErrUnauthorized
+	case amqp.PreconditionFailed, amqp.ResourceLocked:
+		return ErrPreconditionFailed
+	case amqp.ConnectionForced:
+		return ErrUnreachable
+	}
+
+	return nil
+}
+
+// Exit codes of buneary. Errors that don't fall into any category result in 1.
+const (
+	exitFailure            = 1
+	exitNotFound           = 2
+	exitUnauthorized       = 3
+	exitPreconditionFailed = 4
+	exitUnreachable        = 5
+)
+
+// exitCode returns the exit code for the given error.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, ErrPreconditionFailed):
+		return exitPreconditionFailed
+	case errors.Is(err, ErrUnreachable):
+		return exitUnreachable
+	}
+
+	return exitFailure
+}
+
+// errorHint returns a hint on how to resolve the given error, or an empty string if
+// there is no hint for it.
+func errorHint(err error) string {
+	var (
+		s      *ServerError
+		reason string
+	)
+
+	if errors.As(err, &s) {
+		reason = strings.ToLower(s.Reason)
+	}
+
+	// The server reports declaring an existing resource with different settings as
+	// `inequivalent arg '<setting>' for <exchange|queue> ...`.
+	inequivalent := strings.Contains(reason, "inequivalent arg")
+
+	switch {
+	case strings.Contains(reason, "inequivalent arg 'type' for exchange"):
+		return "exchange exists with different type; delete it first"
+	case inequivalent && strings.Contains(reason, "for exchange"):
+		return "exchange exists with different settings; delete it first"
+	case strings.Contains(reason, "inequivalent arg 'x-queue-type' for queue"):
+		return "queue exists with different type; delete it first"
+	case inequivalent && strings.Contains(reason, "for queue"):
+		return "queue exists with different settings; delete it first"
+	case strings.Contains(reason, "not empty"):
+		return "queue still holds messages; purge it first or delete it without --if-empty"
+	case strings.Contains(reason, "in use"):
+		return "resource is still in use; remove its bindings or consumers first or delete it without --if-unused"
+	case strings.Contains(reason, "reserved prefix"), strings.Contains(reason, "not permitted"):
+		return "the default exchange and names starting with amq. are reserved by the server"
+	case errors.Is(err, ErrNotFound):
+		return "check the name and the virtual host passed with --vhost"
+	case errors.Is(err, ErrUnauthorized):
+		return "check the credentials and the permissions of the user for the virtual host"
+	case errors.Is(err, ErrUnreachable):
+		return "check the server address and that the management plugin is enabled"
+	}
+
+	return ""
+}
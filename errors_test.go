@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	rabbithole "github.com/michaelklishin/rabbit-hole/v2"
+	"github.com/streadway/amqp"
+)
+
+func TestServerError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+		code int
+	}{
+		{
+			name: "rabbit-hole not found",
+			err:  rabbithole.ErrorResponse{StatusCode: 404, Message: "Object Not Found", Reason: "Not Found"},
+			kind: ErrNotFound,
+			code: 404,
+		},
+		{
+			name: "rabbit-hole inequivalent arg",
+			err:  rabbithole.ErrorResponse{StatusCode: 400, Message: "bad_request", Reason: "inequivalent arg 'type' for exchange 'orders' in vhost '/'"},
+			kind: ErrPreconditionFailed,
+			code: 400,
+		},
+		{
+			name: "rabbit-hole bad request",
+			err:  rabbithole.ErrorResponse{StatusCode: 400, Message: "bad_request", Reason: "invalid JSON"},
+			code: 400,
+		},
+		{
+			name: "rabbit-hole unauthorized",
+			err:  errors.New("Error: API responded with a 401 Unauthorized"),
+			kind: ErrUnauthorized,
+			code: 401,
+		},
+		{
+			name: "AMQP not found",
+			err:  &amqp.Error{Code: amqp.NotFound, Reason: "NOT_FOUND - no exchange 'orders' in vhost '/'"},
+			kind: ErrNotFound,
+			code: amqp.NotFound,
+		},
+		{
+			name: "AMQP access refused",
+			err:  amqp.ErrCredentials,
+			kind: ErrUnauthorized,
+			code: amqp.AccessRefused,
+		},
+		{
+			name: "AMQP precondition failed",
+			err:  &amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - inequivalent arg 'durable' for queue 'orders' in vhost '/'"},
+			kind: ErrPreconditionFailed,
+			code: amqp.PreconditionFailed,
+		},
+		{
+			name: "AMQP connection forced",
+			err:  &amqp.Error{Code: amqp.ConnectionForced, Reason: "CONNECTION_FORCED - broker forced connection closure"},
+			kind: ErrUnreachable,
+			code: amqp.ConnectionForced,
+		},
+		{
+			name: "AMQP channel error",
+			err:  &amqp.Error{Code: amqp.ChannelError, Reason: "CHANNEL_ERROR - expected 'channel.open'"},
+			code: amqp.ChannelError,
+		},
+		{
+			name: "wrapped AMQP error",
+			err:  fmt.Errorf("declaring queue: %w", &amqp.Error{Code: amqp.NotFound}),
+			kind: ErrNotFound,
+			code: amqp.NotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := serverError(test.err)
+
+			var s *ServerError
+
+			if !errors.As(err, &s) {
+				t.Fatalf("expected a *ServerError, got %T", err)
+			}
+
+			if s.Kind != test.kind {
+				t.Errorf("expected kind %v, got %v", test.kind, s.Kind)
+			}
+
+			if s.Code != test.code {
+				t.Errorf("expected code %d, got %d", test.code, s.Code)
+			}
+
+			if test.kind != nil && !errors.Is(err, test.kind) {
+				t.Errorf("expected error to match %v", test.kind)
+			}
+
+			if s.Err != test.err {
+				t.Errorf("expected error to wrap %v, got %v", test.err, s.Err)
+			}
+
+			if err.Error() != test.err.Error() {
+				t.Errorf("expected message %q, got %q", test.err.Error(), err.Error())
+			}
+		})
+	}
+
+	if err := serverError(nil); err != nil {
+		t.Errorf("expected nil, got %v", err)
+	}
+
+	plain := errors.New("marshalling request body")
+
+	if err := serverError(plain); err != plain {
+		t.Errorf("expected unrelated error to be returned as it is, got %v", err)
+	}
+}
+
+func TestErrorHint(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		exitCode int
+		hint     string
+	}{
+		{
+			name:     "exchange with different type",
+			err:      fmt.Errorf("declaring exchange: %w", memoryError(ErrPreconditionFailed, "inequivalent arg 'type' for exchange 'orders' in vhost '/': received 'fanout' but current is 'topic'")),
+			exitCode: exitPreconditionFailed,
+			hint:     "exchange exists with different type; delete it first",
+		},
+		{
+			name:     "queue with different settings",
+			err:      serverError(&amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - inequivalent arg 'durable' for queue 'orders' in vhost '/'"}),
+			exitCode: exitPreconditionFailed,
+			hint:     "queue exists with different settings; delete it first",
+		},
+		{
+			name:     "invalid queue argument",
+			err:      serverError(&amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - invalid arg 'x-max-length' for queue 'orders' in vhost '/': {value_negative,-1}"}),
+			exitCode: exitPreconditionFailed,
+		},
+		{
+			name:     "queue not empty",
+			err:      memoryError(ErrPreconditionFailed, "queue 'orders' in vhost '/' not empty"),
+			exitCode: exitPreconditionFailed,
+			hint:     "queue still holds messages; purge it first or delete it without --if-empty",
+		},
+		{
+			name:     "not found",
+			err:      fmt.Errorf("queue orders: %w", ErrNotFound),
+			exitCode: exitNotFound,
+			hint:     "check the name and the virtual host passed with --vhost",
+		},
+		{
+			name:     "unauthorized",
+			err:      serverError(amqp.ErrCredentials),
+			exitCode: exitUnauthorized,
+			hint:     "check the credentials and the permissions of the user for the virtual host",
+		},
+		{
+			name:     "unreachable",
+			err:      &ServerError{Kind: ErrUnreachable, Err: errors.New("connection refused")},
+			exitCode: exitUnreachable,
+			hint:     "check the server address and that the management plugin is enabled",
+		},
+		{
+			name:     "other error",
+			err:      errors.New("--regex and --prefix can't be used together"),
+			exitCode: exitFailure,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := exitCode(test.err); code != test.exitCode {
+				t.Errorf("expected exit code %d, got %d", test.exitCode, code)
+			}
+
+			if hint := errorHint(test.err); hint != test.hint {
+				t.Errorf("expected hint %q, got %q", test.hint, hint)
+			}
+		})
+	}
+}
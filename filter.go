@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// filterOperators lists all operators supported by filter expressions. Operators that
+// start with another operator have to be listed first, so that they're found first.
+var filterOperators = []string{"=~", "!~", "!=", ">=", "<=", "=", ">", "<"}
+
+// FilterExpression is a single condition on a resource field such as `messages>1000`
+// or `name=~^orders\.`. Field names are case-insensitive, and hyphens or underscores
+// are ignored, so that `messages_unack` refers to the MessagesUnAck field.
+type FilterExpression struct {
+	Field    string
+	Operator string
+	Value    string
+	pattern  *regexp.Regexp
+}
+
+// ParseFilterExpression parses a filter expression in the form <field><operator><value>.
+// The supported operators are `=`, `!=`, `>`, `>=`, `<`, `<=` as well as `=~` and `!~`
+// for matching and not matching a regular expression.
+func ParseFilterExpression(source string) (*FilterExpression, error) {
+	index, operator := -1, ""
+
+	// Find the operator that occurs first. If multiple operators start at the same
+	// index, the longer one wins because of the order of filterOperators.
+	for _, candidate := range filterOperators {
+		i := strings.Index(source, candidate)
+		if i >= 0 && (index < 0 || i < index) {
+			index, operator = i, candidate
+		}
+	}
+
+	if index <= 0 {
+		return nil, fmt.Errorf("invalid filter %q, expected <field><operator><value>", source)
+	}
+
+	expression := &FilterExpression{
+		Field:    strings.TrimSpace(source[:index]),
+		Operator: operator,
+		Value:    strings.TrimSpace(source[index+len(operator):]),
+	}
+
+	if operator == "=~" || operator == "!~" {
+		pattern, err := regexp.Compile(expression.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %w", source, err)
+		}
+		expression.pattern = pattern
+	}
+
+	return expression, nil
+}
+
+// CompileFilters parses the given filter expressions and returns a function that
+// determines whether a resource satisfies all of them. The resource passed in has
+// to be of the same type as the given prototype, e.g. Queue{}, which is used for
+// checking whether the fields exist and whether the values have the correct type.
+func CompileFilters(sources []string, prototype interface{}) (func(resource interface{}) bool, error) {
+	var expressions []*FilterExpression
+
+	for _, source := range sources {
+		expression, err := ParseFilterExpression(source)
+		if err != nil {
+			return nil, err
+		}
+
+		field, ok := resourceField(reflect.ValueOf(prototype), expression.Field)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", expression.Field,
+				strings.Join(resourceFieldNames(prototype), ", "))
+		}
+
+		if _, err := expression.matches(field); err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, expression)
+	}
+
+	match := func(resource interface{}) bool {
+		for _, expression := range expressions {
+			field, _ := resourceField(reflect.ValueOf(resource), expression.Field)
+			if ok, _ := expression.matches(field); !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	return match, nil
+}
+
+// matches determines whether the given field value satisfies the expression. Numbers
+// are compared numerically and strings lexically, whereas booleans can only be
+// compared for equality. Regular expressions are matched against the string value.
+func (f *FilterExpression) matches(field reflect.Value) (bool, error) {
+	if f.pattern != nil {
+		matched := f.pattern.MatchString(fieldToString(field))
+		return matched == (f.Operator == "=~"), nil
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		value, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return false, fmt.Errorf("field %s is numeric, but %q is not a number", f.Field, f.Value)
+		}
+		return compare(fieldToFloat(field), value, f.Operator), nil
+
+	case reflect.Bool:
+		value, err := parseBool(f.Value)
+		if err != nil {
+			return false, fmt.Errorf("field %s is a boolean, but %q is not a boolean", f.Field, f.Value)
+		}
+		if f.Operator != "=" && f.Operator != "!=" {
+			return false, fmt.Errorf("field %s is a boolean and only supports = and !=", f.Field)
+		}
+		return (field.Bool() == value) == (f.Operator == "="), nil
+	}
+
+	actual := fieldToString(field)
+
+	switch f.Operator {
+	case "=":
+		return actual == f.Value, nil
+	case "!=":
+		return actual != f.Value, nil
+	case ">":
+		return actual > f.Value, nil
+	case ">=":
+		return actual >= f.Value, nil
+	case "<":
+		return actual < f.Value, nil
+	case "<=":
+		return actual <= f.Value, nil
+	}
+
+	return false, fmt.Errorf("unsupported operator %s", f.Operator)
+}
+
+// SortResources sorts the given slice of resources, e.g. []Queue, by the given field.
+// The sort order is ascending, unless the field is suffixed with `:desc`. The suffix
+// `:asc` is accepted as well. If sortBy is empty, the slice remains unchanged.
+func SortResources(resources interface{}, sortBy string) error {
+	if sortBy == "" {
+		return nil
+	}
+
+	name, descending, err := parseSortBy(sortBy)
+	if err != nil {
+		return err
+	}
+
+	slice := reflect.ValueOf(resources)
+	prototype := reflect.Zero(slice.Type().Elem())
+
+	if _, ok := resourceField(prototype, name); !ok {
+		return fmt.Errorf("unknown field %q, expected one of %s", name,
+			strings.Join(resourceFieldNames(prototype.Interface()), ", "))
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, _ := resourceField(slice.Index(i), name)
+		b, _ := resourceField(slice.Index(j), name)
+
+		if descending {
+			a, b = b, a
+		}
+
+		switch a.Kind() {
+		case reflect.Int, reflect.Int64, reflect.Float64:
+			return fieldToFloat(a) < fieldToFloat(b)
+		case reflect.Bool:
+			return !a.Bool() && b.Bool()
+		}
+
+		return fieldToString(a) < fieldToString(b)
+	})
+
+	return nil
+}
+
+// parseSortBy splits the given sort specification like `messages:desc` into the field
+// name and the sort order. Without an `:asc` or `:desc` suffix, the order is ascending.
+func parseSortBy(sortBy string) (string, bool, error) {
+	i := strings.LastIndex(sortBy, ":")
+	if i < 0 {
+		return sortBy, false, nil
+	}
+
+	order := sortBy[i+1:]
+	if order != "asc" && order != "desc" {
+		return "", false, fmt.Errorf("invalid sort order %q, expected asc or desc", order)
+	}
+
+	return sortBy[:i], order == "desc", nil
+}
+
+// LimitResources truncates the slice of resources the given pointer points to, so that
+// it contains at most limit elements. A limit of zero or less means no limit.
+func LimitResources(resources interface{}, limit int) {
+	slice := reflect.ValueOf(resources).Elem()
+
+	if limit > 0 && slice.Len() > limit {
+		slice.Set(slice.Slice(0, limit))
+	}
+}
+
+// resourceField returns the field of the given resource struct with the given name.
+// Names are compared case-insensitively, ignoring hyphens and underscores. If the
+// field itself is a struct with a Name field, like Binding.From, the name is used.
+func resourceField(resource reflect.Value, name string) (reflect.Value, bool) {
+	normalized := normalizeFieldName(name)
+
+	for i := 0; i < resource.NumField(); i++ {
+		if normalizeFieldName(resource.Type().Field(i).Name) != normalized {
+			continue
+		}
+
+		field := resource.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if nested := field.FieldByName("Name"); nested.IsValid() {
+				return nested, true
+			}
+		}
+
+		return field, true
+	}
+
+	return reflect.Value{}, false
+}
+
+// resourceFieldNames returns the lowercase names of all fields of the given resource.
+func resourceFieldNames(resource interface{}) []string {
+	var (
+		t     = reflect.TypeOf(resource)
+		names = make([]string, t.NumField())
+	)
+
+	for i := range names {
+		names[i] = strings.ToLower(t.Field(i).Name)
+	}
+
+	return names
+}
+
+// normalizeFieldName lowercases the given field name and removes hyphens and underscores.
+func normalizeFieldName(name string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+// fieldToString returns the string representation of the given field value. Maps like
+// queue arguments are rendered as sorted key=value pairs.
+func fieldToString(field reflect.Value) string {
+	if arguments, ok := field.Interface().(map[string]interface{}); ok {
+		return argumentsToString(arguments)
+	}
+	return fmt.Sprint(field.Interface())
+}
+
+// fieldToFloat returns the value of the given numeric field as float64.
+func fieldToFloat(field reflect.Value) float64 {
+	if field.Kind() == reflect.Float64 {
+		return field.Float()
+	}
+	return float64(field.Int())
+}
+
+// compare compares two numbers using the given operator.
+func compare(a, b float64, operator string) bool {
+	switch operator {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
+
+// parseBool parses a boolean value. In addition to the values accepted by
+// strconv.ParseBool, `yes` and `no` are accepted since they're used in the output.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
@@ -3,9 +3,12 @@ module github.com/dominikbraun/buneary
 go 1.14
 
 require (
+	github.com/gdamore/tcell/v2 v2.0.1-0.20201017141208-acf90d56d591
 	github.com/michaelklishin/rabbit-hole/v2 v2.6.0
 	github.com/olekukonko/tablewriter v0.0.4
+	github.com/rivo/tview v0.0.0-20210125085121-dbc1f32bb1d0
 	github.com/spf13/cobra v1.1.1
 	github.com/streadway/amqp v1.0.0
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	gopkg.in/yaml.v2 v2.3.0
 )
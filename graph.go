@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphFormat represents an output format for rendering a topology graph.
+type GraphFormat string
+
+const (
+	// DOT renders the graph in the Graphviz DOT language.
+	DOT GraphFormat = "dot"
+
+	// Mermaid renders the graph as Mermaid flowchart.
+	Mermaid = "mermaid"
+)
+
+// Graph represents the routing topology of a RabbitMQ server, where exchanges and
+// queues are the nodes and the bindings between them are the edges.
+type Graph struct {
+	Exchanges []Exchange
+	Queues    []Queue
+	Bindings  []Binding
+}
+
+// BuildGraph reads all exchanges, queues and bindings from the given provider. If a
+// root exchange is given, the graph will only contain the resources reachable from
+// that exchange by following its bindings, including exchange-to-exchange bindings.
+//
+// The default exchange and its implicit bindings to all queues are always skipped,
+// since they would connect each queue to the same node and clutter the graph.
+//
+// Nodes are identified by their names, so the provider has to be configured with a
+// single virtual host.
+func BuildGraph(provider Provider, root string) (*Graph, error) {
+	exchanges, err := provider.GetExchanges(func(exchange Exchange) bool {
+		return exchange.Name != ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queues, err := provider.GetQueues(func(_ Queue) bool {
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bindings, err := provider.GetBindings(func(binding Binding) bool {
+		return binding.From.Name != ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &Graph{
+		Exchanges: exchanges,
+		Queues:    queues,
+		Bindings:  bindings,
+	}
+
+	if root == "" {
+		return graph, nil
+	}
+
+	return graph.reachableFrom(root)
+}
+
+// reachableFrom returns the subgraph containing all nodes and edges that are reachable
+// from the given root exchange. Returns an error if the root exchange doesn't exist.
+func (g *Graph) reachableFrom(root string) (*Graph, error) {
+	var (
+		reached   = map[string]bool{resourceKey(ToExchange, root): true}
+		pending   = []string{root}
+		subgraph  = &Graph{}
+		rootFound = false
+	)
+
+	for _, exchange := range g.Exchanges {
+		if exchange.Name == root {
+			rootFound = true
+		}
+	}
+
+	if !rootFound {
+		return nil, fmt.Errorf("exchange %s not found", root)
+	}
+
+	for len(pending) > 0 {
+		source := pending[0]
+		pending = pending[1:]
+
+		for _, binding := range g.Bindings {
+			if binding.From.Name != source {
+				continue
+			}
+
+			subgraph.Bindings = append(subgraph.Bindings, binding)
+			key := resourceKey(binding.Type, binding.TargetName)
+
+			if reached[key] {
+				continue
+			}
+			reached[key] = true
+
+			if binding.Type == ToExchange {
+				pending = append(pending, binding.TargetName)
+			}
+		}
+	}
+
+	for _, exchange := range g.Exchanges {
+		if reached[resourceKey(ToExchange, exchange.Name)] {
+			subgraph.Exchanges = append(subgraph.Exchanges, exchange)
+		}
+	}
+
+	for _, queue := range g.Queues {
+		if reached[resourceKey(ToQueue, queue.Name)] {
+			subgraph.Queues = append(subgraph.Queues, queue)
+		}
+	}
+
+	return subgraph, nil
+}
+
+// Render renders the graph in the given format. Exchanges and queues are rendered as
+// differently shaped nodes, and bindings as edges labeled with their binding key.
+func (g *Graph) Render(format GraphFormat) (string, error) {
+	switch format {
+	case DOT:
+		return g.renderDOT(), nil
+	case Mermaid:
+		return g.renderMermaid(), nil
+	}
+	return "", fmt.Errorf("unknown graph format %q, expected one of %s, %s", format, DOT, Mermaid)
+}
+
+// renderDOT renders the graph in the Graphviz DOT language. The node identifiers are
+// the quoted resource keys, so that exchanges and queues with the same name differ.
+func (g *Graph) renderDOT() string {
+	var builder strings.Builder
+
+	builder.WriteString("digraph buneary {\n")
+	builder.WriteString("  rankdir=LR;\n")
+
+	for _, exchange := range g.Exchanges {
+		label := fmt.Sprintf("%s\\n(%s)", exchange.Name, exchange.Type)
+		builder.WriteString(fmt.Sprintf("  %s [shape=hexagon, label=%s];\n",
+			dotQuote(resourceKey(ToExchange, exchange.Name)), dotQuote(label)))
+	}
+
+	for _, queue := range g.Queues {
+		builder.WriteString(fmt.Sprintf("  %s [shape=cylinder, label=%s];\n",
+			dotQuote(resourceKey(ToQueue, queue.Name)), dotQuote(queue.Name)))
+	}
+
+	for _, binding := range g.Bindings {
+		builder.WriteString(fmt.Sprintf("  %s -> %s [label=%s];\n",
+			dotQuote(resourceKey(ToExchange, binding.From.Name)),
+			dotQuote(resourceKey(binding.Type, binding.TargetName)),
+			dotQuote(binding.Key)))
+	}
+
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// renderMermaid renders the graph as Mermaid flowchart. Since Mermaid node identifiers
+// are restricted to simple characters, the nodes are numbered and the actual resource
+// names are used as node labels.
+func (g *Graph) renderMermaid() string {
+	var (
+		builder strings.Builder
+		ids     = make(map[string]string)
+	)
+
+	builder.WriteString("flowchart LR\n")
+
+	for i, exchange := range g.Exchanges {
+		id := fmt.Sprintf("e%d", i)
+		ids[resourceKey(ToExchange, exchange.Name)] = id
+		label := fmt.Sprintf("%s<br/>(%s)", exchange.Name, exchange.Type)
+		builder.WriteString(fmt.Sprintf("  %s{{%s}}\n", id, mermaidQuote(label)))
+	}
+
+	for i, queue := range g.Queues {
+		id := fmt.Sprintf("q%d", i)
+		ids[resourceKey(ToQueue, queue.Name)] = id
+		builder.WriteString(fmt.Sprintf("  %s[(%s)]\n", id, mermaidQuote(queue.Name)))
+	}
+
+	for _, binding := range g.Bindings {
+		from, ok := ids[resourceKey(ToExchange, binding.From.Name)]
+		if !ok {
+			continue
+		}
+
+		to, ok := ids[resourceKey(binding.Type, binding.TargetName)]
+		if !ok {
+			continue
+		}
+
+		if binding.Key == "" {
+			builder.WriteString(fmt.Sprintf("  %s --> %s\n", from, to))
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("  %s -->|%s| %s\n", from, mermaidQuote(binding.Key), to))
+	}
+
+	return builder.String()
+}
+
+// dotQuote returns the given string as quoted DOT identifier.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// mermaidQuote returns the given string as quoted Mermaid label. Double quotes within
+// the string are replaced with the corresponding Mermaid entity code.
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
@@ -2,10 +2,24 @@ package main
 
 import (
 	"log"
+	"os"
 )
 
 func main() {
-	if err := rootCommand().Execute(); err != nil {
-		log.Fatal(err)
+	// Errors are printed with hints for the user, so timestamps aren't helpful.
+	log.SetFlags(0)
+
+	options := globalOptions{
+		out: os.Stdout,
+	}
+
+	if err := rootCommand(&options).Execute(); err != nil {
+		log.Print(err)
+
+		if hint := errorHint(err); hint != "" {
+			log.Printf("hint: %s", hint)
+		}
+
+		os.Exit(exitCode(err))
 	}
 }
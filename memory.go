@@ -0,0 +1,1326 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// InMemoryProvider is a Provider that keeps all resources in memory instead of talking
+// to a RabbitMQ server, which makes it suitable for tests that don't have access to a
+// server. It models exchanges, queues and bindings including the routing of published
+// messages, as well as users, virtual hosts, permissions and policies.
+//
+// All exchanges, queues and bindings belong to the default virtual host `/`. There are
+// no clients, so connections, channels and consumers only exist if they have been added
+// using Connect.
+type InMemoryProvider struct {
+	mutex       sync.Mutex
+	exchanges   map[string]Exchange
+	queues      map[string]*memoryQueue
+	bindings    []Binding
+	users       map[string]User
+	vhosts      map[string]Vhost
+	permissions []Permissions
+	policies    []Policy
+	connections []Connection
+	channels    []Channel
+	consumers   []Consumer
+	generated   int
+}
+
+// memoryQueue is a queue along with the messages it holds.
+type memoryQueue struct {
+	queue    Queue
+	messages []memoryMessage
+}
+
+// memoryMessage is a message along with the time it has been published at, which is
+// required for reading stream messages from a given point in time.
+type memoryMessage struct {
+	Message
+	published time.Time
+}
+
+// memoryDefaultExchanges are the exchanges pre-declared by the server.
+var memoryDefaultExchanges = []Exchange{
+	{Name: "", Type: Direct, Durable: true},
+	{Name: "amq.direct", Type: Direct, Durable: true},
+	{Name: "amq.fanout", Type: Fanout, Durable: true},
+	{Name: "amq.headers", Type: Headers, Durable: true},
+	{Name: "amq.match", Type: Headers, Durable: true},
+	{Name: "amq.topic", Type: Topic, Durable: true},
+}
+
+// NewInMemoryProvider creates a new InMemoryProvider that behaves like a fresh server.
+// Only the pre-defined exchanges and the default virtual host `/` exist.
+func NewInMemoryProvider() *InMemoryProvider {
+	m := InMemoryProvider{}
+	m.reset()
+
+	return &m
+}
+
+// reset removes all resources and re-creates the pre-defined ones.
+func (m *InMemoryProvider) reset() {
+	m.exchanges = make(map[string]Exchange)
+	m.queues = make(map[string]*memoryQueue)
+	m.bindings = nil
+	m.users = make(map[string]User)
+	m.vhosts = map[string]Vhost{"/": {Name: "/"}}
+	m.permissions = nil
+	m.policies = nil
+	m.connections = nil
+	m.channels = nil
+	m.consumers = nil
+
+	for _, exchange := range memoryDefaultExchanges {
+		m.exchanges[exchange.Name] = exchange
+	}
+}
+
+// CreateExchange creates the given exchange. See Provider.CreateExchange for details.
+//
+// Like the server, it refuses to re-declare an existing exchange with different
+// settings and to declare new exchanges with the reserved `amq.` prefix.
+func (m *InMemoryProvider) CreateExchange(exchange Exchange) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if existing, ok := m.exchanges[exchange.Name]; ok {
+		if err := equivalentExchange(existing, exchange); err != nil {
+			return fmt.Errorf("declaring exchange: %w", err)
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(exchange.Name, "amq.") {
+		return fmt.Errorf("declaring exchange: %w", memoryError(ErrUnauthorized, "exchange name '%s' contains reserved prefix 'amq.*'", exchange.Name))
+	}
+
+	m.exchanges[exchange.Name] = exchange
+
+	return nil
+}
+
+// CreateQueue creates the given queue. See Provider.CreateQueue for details.
+//
+// Like the server, it refuses to re-declare an existing queue with different settings.
+// Queues without a name receive a generated name starting with `amq.gen-`.
+func (m *InMemoryProvider) CreateQueue(queue Queue) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if queue.Type == "" {
+		queue.Type = Classic
+	}
+
+	if queue.Name == "" {
+		m.generated++
+		queue.Name = fmt.Sprintf("amq.gen-%d", m.generated)
+	}
+
+	if existing, ok := m.queues[queue.Name]; ok {
+		if err := equivalentQueue(existing.queue, queue); err != nil {
+			return "", fmt.Errorf("declaring queue: %w", err)
+		}
+		return queue.Name, nil
+	}
+
+	m.queues[queue.Name] = &memoryQueue{
+		queue: queue,
+	}
+
+	return queue.Name, nil
+}
+
+// CreateBinding creates the given binding. See Provider.CreateBinding for details.
+//
+// Both the source exchange and the target have to exist. The default exchange can't
+// be bound explicitly.
+func (m *InMemoryProvider) CreateBinding(binding Binding) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if binding.From.Name == "" {
+		return fmt.Errorf("declaring binding: %w", memoryError(ErrUnauthorized, "operation not permitted on the default exchange"))
+	}
+
+	if _, ok := m.exchanges[binding.From.Name]; !ok {
+		return fmt.Errorf("declaring binding: %w", memoryError(ErrNotFound, "no exchange '%s' in vhost '/'", binding.From.Name))
+	}
+
+	if !m.exists(binding.Type, binding.TargetName) {
+		return fmt.Errorf("declaring binding: %w", memoryError(ErrNotFound, "no %s '%s' in vhost '/'", binding.Type, binding.TargetName))
+	}
+
+	// Like the server, only the name of the source exchange is reported.
+	binding.From = Exchange{Name: binding.From.Name}
+
+	for _, existing := range m.bindings {
+		if sameBinding(existing, binding) {
+			return nil
+		}
+	}
+
+	m.bindings = append(m.bindings, binding)
+
+	return nil
+}
+
+// CreateUser creates or updates the given user. See Provider.CreateUser for details.
+func (m *InMemoryProvider) CreateUser(user User) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.users[user.Name] = user
+
+	return nil
+}
+
+// CreateVhost creates or updates the given virtual host. See Provider.CreateVhost for
+// details.
+func (m *InMemoryProvider) CreateVhost(vhost Vhost) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.vhosts[vhost.Name] = Vhost{
+		Name:             vhost.Name,
+		Description:      vhost.Description,
+		Tags:             vhost.Tags,
+		DefaultQueueType: vhost.DefaultQueueType,
+	}
+
+	return nil
+}
+
+// CreatePolicy creates or updates the given policy. See Provider.CreatePolicy for
+// details.
+func (m *InMemoryProvider) CreatePolicy(policy Policy) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, err := regexp.Compile(policy.Pattern); err != nil {
+		return fmt.Errorf("creating policy: invalid pattern: %w", err)
+	}
+
+	policy.Vhost = "/"
+
+	for i, existing := range m.policies {
+		if existing.Name == policy.Name && existing.Operator == policy.Operator {
+			m.policies[i] = policy
+			return nil
+		}
+	}
+
+	m.policies = append(m.policies, policy)
+
+	return nil
+}
+
+// GetExchanges returns exchanges passing the filter. See Provider.GetExchanges for
+// details.
+func (m *InMemoryProvider) GetExchanges(filter func(exchange Exchange) bool) ([]Exchange, error) {
+	var exchanges []Exchange
+
+	err := m.StreamExchanges(ListQuery{}, filter, func(page []Exchange) error {
+		exchanges = append(exchanges, page...)
+		return nil
+	})
+
+	return exchanges, err
+}
+
+// GetQueues returns queues passing the filter. See Provider.GetQueues for details.
+func (m *InMemoryProvider) GetQueues(filter func(queue Queue) bool) ([]Queue, error) {
+	var queues []Queue
+
+	err := m.StreamQueues(ListQuery{}, filter, func(page []Queue) error {
+		queues = append(queues, page...)
+		return nil
+	})
+
+	return queues, err
+}
+
+// StreamExchanges reads exchanges page by page. See Provider.StreamExchanges for
+// details.
+func (m *InMemoryProvider) StreamExchanges(query ListQuery, filter func(exchange Exchange) bool, handle func(exchanges []Exchange) error) error {
+	match, err := query.matcher()
+	if err != nil {
+		return fmt.Errorf("listing exchanges: %w", err)
+	}
+
+	m.mutex.Lock()
+
+	var exchanges []Exchange
+
+	for _, exchange := range m.exchanges {
+		if match(exchange.Name) && filter(exchange) {
+			exchanges = append(exchanges, exchange)
+		}
+	}
+
+	m.mutex.Unlock()
+
+	if err := query.sort(exchanges, func(i, j int) bool {
+		return exchanges[i].Name < exchanges[j].Name
+	}); err != nil {
+		return fmt.Errorf("listing exchanges: %w", err)
+	}
+
+	size := query.pageSize()
+
+	for start := 0; start < len(exchanges); start += size {
+		end := start + size
+		if end > len(exchanges) {
+			end = len(exchanges)
+		}
+		if err := handle(exchanges[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StreamQueues reads queues page by page. See Provider.StreamQueues for details.
+func (m *InMemoryProvider) StreamQueues(query ListQuery, filter func(queue Queue) bool, handle func(queues []Queue) error) error {
+	match, err := query.matcher()
+	if err != nil {
+		return fmt.Errorf("listing queues: %w", err)
+	}
+
+	m.mutex.Lock()
+
+	var queues []Queue
+
+	for _, q := range m.queues {
+		queue := q.queue
+		queue.Messages = len(q.messages)
+		queue.Consumers = m.queueConsumers(queue.Name)
+		queue.Policy = m.effectivePolicy(queue.Name)
+
+		if match(queue.Name) && filter(queue) {
+			queues = append(queues, queue)
+		}
+	}
+
+	m.mutex.Unlock()
+
+	if err := query.sort(queues, func(i, j int) bool {
+		return queues[i].Name < queues[j].Name
+	}); err != nil {
+		return fmt.Errorf("listing queues: %w", err)
+	}
+
+	size := query.pageSize()
+
+	for start := 0; start < len(queues); start += size {
+		end := start + size
+		if end > len(queues) {
+			end = len(queues)
+		}
+		if err := handle(queues[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetBindings returns bindings passing the filter. See Provider.GetBindings for details.
+//
+// Like the server, it reports the implicit binding of the default exchange to each
+// queue, using the queue name as binding key.
+func (m *InMemoryProvider) GetBindings(filter func(binding Binding) bool) ([]Binding, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var (
+		bindings []Binding
+		names    = make([]string, 0, len(m.queues))
+	)
+
+	for name := range m.queues {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		b := Binding{
+			Type:       ToQueue,
+			From:       Exchange{Name: ""},
+			TargetName: name,
+			Key:        name,
+		}
+
+		if filter(b) {
+			bindings = append(bindings, b)
+		}
+	}
+
+	for _, b := range m.bindings {
+		if filter(b) {
+			bindings = append(bindings, b)
+		}
+	}
+
+	return bindings, nil
+}
+
+// GetUsers returns users passing the filter. See Provider.GetUsers for details.
+// Passwords aren't returned, just like the server only returns password hashes.
+func (m *InMemoryProvider) GetUsers(filter func(user User) bool) ([]User, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var users []User
+
+	for _, user := range m.users {
+		user.Password = ""
+
+		if filter(user) {
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+
+	return users, nil
+}
+
+// GetPermissions returns permissions passing the filter. See Provider.GetPermissions
+// for details.
+func (m *InMemoryProvider) GetPermissions(filter func(permissions Permissions) bool) ([]Permissions, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var permissions []Permissions
+
+	for _, p := range m.permissions {
+		if filter(p) {
+			permissions = append(permissions, p)
+		}
+	}
+
+	return permissions, nil
+}
+
+// GetVhosts returns virtual hosts passing the filter. See Provider.GetVhosts for
+// details.
+func (m *InMemoryProvider) GetVhosts(filter func(vhost Vhost) bool) ([]Vhost, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var vhosts []Vhost
+
+	for _, vhost := range m.vhosts {
+		if vhost.Name == "/" {
+			for _, q := range m.queues {
+				vhost.Messages += len(q.messages)
+				vhost.MessagesReady += len(q.messages)
+			}
+		}
+
+		if filter(vhost) {
+			vhosts = append(vhosts, vhost)
+		}
+	}
+
+	sort.Slice(vhosts, func(i, j int) bool {
+		return vhosts[i].Name < vhosts[j].Name
+	})
+
+	return vhosts, nil
+}
+
+// GetPolicies returns policies passing the filter. See Provider.GetPolicies for details.
+func (m *InMemoryProvider) GetPolicies(filter func(policy Policy) bool) ([]Policy, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var policies []Policy
+
+	for _, policy := range m.policies {
+		if filter(policy) {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies, nil
+}
+
+// GetMessages reads messages from the given queue. See Provider.GetMessages for details.
+//
+// Messages are removed from the queue unless requeue is true, in which case they stay
+// at the head of the queue and will be returned again by the next call.
+func (m *InMemoryProvider) GetMessages(queue Queue, max int, requeue bool) ([]Message, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	q, ok := m.queues[queue.Name]
+	if !ok {
+		return nil, memoryError(ErrNotFound, "no queue '%s' in vhost '/'", queue.Name)
+	}
+
+	count := max
+	if count > len(q.messages) {
+		count = len(q.messages)
+	}
+
+	messages := make([]Message, count)
+
+	for i := range messages {
+		messages[i] = q.messages[i].Message
+	}
+
+	if !requeue {
+		q.messages = q.messages[count:]
+	}
+
+	return messages, nil
+}
+
+// GetStreamMessages reads messages from the given stream. See Provider.GetStreamMessages
+// for details. The queue has to be a stream, and the messages remain in the stream.
+func (m *InMemoryProvider) GetStreamMessages(queue Queue, offset interface{}, max int) ([]Message, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	q, ok := m.queues[queue.Name]
+	if !ok {
+		return nil, fmt.Errorf("consuming stream: %w", memoryError(ErrNotFound, "no queue '%s' in vhost '/'", queue.Name))
+	}
+
+	if q.queue.Type != Stream {
+		return nil, fmt.Errorf("consuming stream: %w", memoryError(ErrPreconditionFailed, "queue '%s' is not a stream", queue.Name))
+	}
+
+	start := len(q.messages)
+
+	switch o := offset.(type) {
+	case string:
+		if o == "first" {
+			start = 0
+		} else if o == "last" && len(q.messages) > 0 {
+			start = len(q.messages) - 1
+		}
+	case int64:
+		if o >= 0 && o < int64(len(q.messages)) {
+			start = int(o)
+		}
+	case time.Time:
+		for i, message := range q.messages {
+			if !message.published.Before(o) {
+				start = i
+				break
+			}
+		}
+	default:
+		return nil, fmt.Errorf("consuming stream: invalid offset %v", offset)
+	}
+
+	var messages []Message
+
+	for i := start; i < len(q.messages) && len(messages) < max; i++ {
+		messages = append(messages, q.messages[i].Message)
+	}
+
+	return messages, nil
+}
+
+// GetDefinitions returns the definitions of all resources. See Provider.GetDefinitions
+// for details. Users, virtual hosts and permissions are only contained if no virtual
+// host is given, and resources only have a `vhost` field in that case.
+func (m *InMemoryProvider) GetDefinitions(vhost string) (*Definitions, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	definitions := &Definitions{
+		RabbitVersion: "in-memory",
+	}
+
+	withVhost := func(definition Definition) Definition {
+		if vhost == "" {
+			definition["vhost"] = "/"
+		}
+		return definition
+	}
+
+	if vhost == "" {
+		for _, user := range m.users {
+			definitions.Users = append(definitions.Users, Definition{
+				"name":              user.Name,
+				"password_hash":     user.PasswordHash,
+				"hashing_algorithm": user.HashingAlgorithm,
+				"tags":              strings.Join(user.Tags, ","),
+			})
+		}
+
+		for _, v := range m.vhosts {
+			definitions.Vhosts = append(definitions.Vhosts, Definition{
+				"name": v.Name,
+			})
+		}
+
+		for _, p := range m.permissions {
+			definitions.Permissions = append(definitions.Permissions, Definition{
+				"user":      p.User,
+				"vhost":     p.Vhost,
+				"configure": p.Configure,
+				"write":     p.Write,
+				"read":      p.Read,
+			})
+		}
+	}
+
+	for _, policy := range m.policies {
+		if policy.Operator {
+			continue
+		}
+		definitions.Policies = append(definitions.Policies, withVhost(Definition{
+			"name":       policy.Name,
+			"pattern":    policy.Pattern,
+			"apply-to":   policy.ApplyTo,
+			"priority":   policy.Priority,
+			"definition": policy.Definition,
+		}))
+	}
+
+	for _, exchange := range m.exchanges {
+		if isSystemExchange(exchange) {
+			continue
+		}
+		definitions.Exchanges = append(definitions.Exchanges, withVhost(Definition{
+			"name":        exchange.Name,
+			"type":        string(exchange.Type),
+			"durable":     exchange.Durable,
+			"auto_delete": exchange.AutoDelete,
+			"internal":    exchange.Internal,
+			"arguments":   nonNilArguments(exchange.Arguments),
+		}))
+	}
+
+	for _, q := range m.queues {
+		arguments := nonNilArguments(q.queue.Arguments)
+		arguments["x-queue-type"] = string(q.queue.Type)
+
+		definitions.Queues = append(definitions.Queues, withVhost(Definition{
+			"name":        q.queue.Name,
+			"durable":     q.queue.Durable,
+			"auto_delete": q.queue.AutoDelete,
+			"arguments":   arguments,
+		}))
+	}
+
+	for _, binding := range m.bindings {
+		definitions.Bindings = append(definitions.Bindings, withVhost(Definition{
+			"source":           binding.From.Name,
+			"destination":      binding.TargetName,
+			"destination_type": string(binding.Type),
+			"routing_key":      binding.Key,
+			"arguments":        nonNilArguments(binding.Arguments),
+		}))
+	}
+
+	for _, resources := range definitions.kinds() {
+		sortDefinitions(*resources)
+	}
+
+	return definitions, nil
+}
+
+// PublishMessage publishes the given message. See Provider.PublishMessage for details.
+//
+// The message is routed using Route, so it lands in the same queues as reported by
+// the `buneary route` command. Messages that can't be routed are dropped.
+func (m *InMemoryProvider) PublishMessage(message Message) error {
+	m.mutex.Lock()
+	exchange, ok := m.exchanges[message.Target.Name]
+	m.mutex.Unlock()
+
+	if !ok {
+		return fmt.Errorf("publishing message: %w", memoryError(ErrNotFound, "no exchange '%s' in vhost '/'", message.Target.Name))
+	}
+
+	if exchange.Internal {
+		return fmt.Errorf("publishing message: %w", memoryError(ErrUnauthorized, "cannot publish to internal exchange '%s'", exchange.Name))
+	}
+
+	results, err := Route(m, message.Target.Name, message.RoutingKey, message.Headers)
+	if err != nil {
+		return fmt.Errorf("publishing message: %w", err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	published := time.Now()
+
+	for _, result := range results {
+		// The queue may have been deleted while the routing was evaluated.
+		if q, ok := m.queues[result.Queue]; ok {
+			q.messages = append(q.messages, memoryMessage{
+				Message:   message,
+				published: published,
+			})
+		}
+	}
+
+	return nil
+}
+
+// ImportDefinitions creates all resources from the given definitions. See
+// Provider.ImportDefinitions for details.
+func (m *InMemoryProvider) ImportDefinitions(vhost string, definitions *Definitions) error {
+	for _, d := range definitions.Users {
+		user := User{
+			Name:             definitionString(d, "name"),
+			PasswordHash:     definitionString(d, "password_hash"),
+			HashingAlgorithm: definitionString(d, "hashing_algorithm"),
+		}
+		if tags := definitionString(d, "tags"); tags != "" {
+			user.Tags = strings.Split(tags, ",")
+		}
+		if err := m.CreateUser(user); err != nil {
+			return fmt.Errorf("importing definitions: %w", err)
+		}
+	}
+
+	for _, d := range definitions.Vhosts {
+		if err := m.CreateVhost(Vhost{Name: definitionString(d, "name")}); err != nil {
+			return fmt.Errorf("importing definitions: %w", err)
+		}
+	}
+
+	for _, d := range definitions.Permissions {
+		err := m.SetPermissions(Permissions{
+			User:      definitionString(d, "user"),
+			Vhost:     definitionString(d, "vhost"),
+			Configure: definitionString(d, "configure"),
+			Write:     definitionString(d, "write"),
+			Read:      definitionString(d, "read"),
+		})
+		if err != nil {
+			return fmt.Errorf("importing definitions: %w", err)
+		}
+	}
+
+	for _, d := range definitions.Policies {
+		priority, _ := d["priority"].(float64)
+		if p, ok := d["priority"].(int); ok {
+			priority = float64(p)
+		}
+
+		err := m.CreatePolicy(Policy{
+			Name:       definitionString(d, "name"),
+			Pattern:    definitionString(d, "pattern"),
+			ApplyTo:    definitionString(d, "apply-to"),
+			Priority:   int(priority),
+			Definition: definitionArguments(d, "definition"),
+		})
+		if err != nil {
+			return fmt.Errorf("importing definitions: %w", err)
+		}
+	}
+
+	for _, d := range definitions.Exchanges {
+		err := m.CreateExchange(Exchange{
+			Name:       definitionString(d, "name"),
+			Type:       ExchangeType(definitionString(d, "type")),
+			Durable:    definitionBool(d, "durable"),
+			AutoDelete: definitionBool(d, "auto_delete"),
+			Internal:   definitionBool(d, "internal"),
+			Arguments:  definitionArguments(d, "arguments"),
+		})
+		if err != nil {
+			return fmt.Errorf("importing definitions: %w", err)
+		}
+	}
+
+	for _, d := range definitions.Queues {
+		arguments := definitionArguments(d, "arguments")
+		queueType, _ := arguments["x-queue-type"].(string)
+		delete(arguments, "x-queue-type")
+
+		_, err := m.CreateQueue(Queue{
+			Name:       definitionString(d, "name"),
+			Type:       QueueType(queueType),
+			Durable:    definitionBool(d, "durable"),
+			AutoDelete: definitionBool(d, "auto_delete"),
+			Arguments:  arguments,
+		})
+		if err != nil {
+			return fmt.Errorf("importing definitions: %w", err)
+		}
+	}
+
+	for _, d := range definitions.Bindings {
+		err := m.CreateBinding(Binding{
+			Type:       BindingType(definitionString(d, "destination_type")),
+			From:       Exchange{Name: definitionString(d, "source")},
+			TargetName: definitionString(d, "destination"),
+			Key:        definitionString(d, "routing_key"),
+			Arguments:  definitionArguments(d, "arguments"),
+		})
+		if err != nil {
+			return fmt.Errorf("importing definitions: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SetPermissions sets the given permissions. See Provider.SetPermissions for details.
+// Both the user and the virtual host have to exist.
+func (m *InMemoryProvider) SetPermissions(permissions Permissions) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if permissions.Vhost == "" {
+		permissions.Vhost = "/"
+	}
+
+	if _, ok := m.users[permissions.User]; !ok {
+		return fmt.Errorf("setting permissions: %w", memoryError(ErrNotFound, "no user '%s'", permissions.User))
+	}
+
+	if _, ok := m.vhosts[permissions.Vhost]; !ok {
+		return fmt.Errorf("setting permissions: %w", memoryError(ErrNotFound, "no vhost '%s'", permissions.Vhost))
+	}
+
+	for i, existing := range m.permissions {
+		if existing.User == permissions.User && existing.Vhost == permissions.Vhost {
+			m.permissions[i] = permissions
+			return nil
+		}
+	}
+
+	m.permissions = append(m.permissions, permissions)
+
+	return nil
+}
+
+// DeleteExchange deletes the given exchange along with all of its bindings. See
+// Provider.DeleteExchange for details.
+func (m *InMemoryProvider) DeleteExchange(exchange Exchange, conditions DeleteConditions) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.exchanges[exchange.Name]; !ok {
+		return fmt.Errorf("deleting exchange: %w", memoryError(ErrNotFound, "no exchange '%s' in vhost '/'", exchange.Name))
+	}
+
+	if isSystemExchange(exchange) {
+		return fmt.Errorf("deleting exchange: %w", memoryError(ErrUnauthorized, "operation not permitted on exchange '%s'", exchange.Name))
+	}
+
+	if conditions.IfUnused {
+		for _, binding := range m.bindings {
+			if binding.From.Name == exchange.Name {
+				return fmt.Errorf("deleting exchange: %w", memoryError(ErrPreconditionFailed, "exchange '%s' in vhost '/' in use", exchange.Name))
+			}
+		}
+	}
+
+	delete(m.exchanges, exchange.Name)
+
+	m.removeBindings(func(binding Binding) bool {
+		return binding.From.Name == exchange.Name ||
+			(binding.Type == ToExchange && binding.TargetName == exchange.Name)
+	})
+
+	return nil
+}
+
+// DeleteQueue deletes the given queue along with all of its bindings. See
+// Provider.DeleteQueue for details.
+func (m *InMemoryProvider) DeleteQueue(queue Queue, conditions DeleteConditions) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	q, ok := m.queues[queue.Name]
+	if !ok {
+		return fmt.Errorf("deleting queue: %w", memoryError(ErrNotFound, "no queue '%s' in vhost '/'", queue.Name))
+	}
+
+	if conditions.IfEmpty && len(q.messages) > 0 {
+		return fmt.Errorf("deleting queue: %w", memoryError(ErrPreconditionFailed, "queue '%s' in vhost '/' not empty", queue.Name))
+	}
+
+	if conditions.IfUnused && m.queueConsumers(queue.Name) > 0 {
+		return fmt.Errorf("deleting queue: %w", memoryError(ErrPreconditionFailed, "queue '%s' in vhost '/' in use", queue.Name))
+	}
+
+	delete(m.queues, queue.Name)
+
+	// Like the server, consumers of a deleted queue are cancelled.
+	m.removeConsumers(func(consumer Consumer) bool {
+		return consumer.Queue == queue.Name
+	})
+
+	m.removeBindings(func(binding Binding) bool {
+		return binding.Type == ToQueue && binding.TargetName == queue.Name
+	})
+
+	return nil
+}
+
+// PurgeQueue removes all messages from the given queue. See Provider.PurgeQueue for
+// details.
+func (m *InMemoryProvider) PurgeQueue(queue Queue) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	q, ok := m.queues[queue.Name]
+	if !ok {
+		return fmt.Errorf("purging queue: %w", memoryError(ErrNotFound, "no queue '%s' in vhost '/'", queue.Name))
+	}
+
+	q.messages = nil
+
+	return nil
+}
+
+// DeleteUser deletes the given user and its permissions. See Provider.DeleteUser for
+// details.
+func (m *InMemoryProvider) DeleteUser(user User) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.users, user.Name)
+
+	m.removePermissions(func(permissions Permissions) bool {
+		return permissions.User == user.Name
+	})
+
+	return nil
+}
+
+// DeleteVhost deletes the given virtual host and its permissions. See
+// Provider.DeleteVhost for details. Deleting the default virtual host `/` also
+// deletes all exchanges, queues and bindings.
+func (m *InMemoryProvider) DeleteVhost(vhost Vhost) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if vhost.Name == "/" {
+		users, vhosts, permissions := m.users, m.vhosts, m.permissions
+		m.reset()
+		m.users, m.vhosts, m.permissions = users, vhosts, permissions
+	}
+
+	delete(m.vhosts, vhost.Name)
+
+	m.removePermissions(func(permissions Permissions) bool {
+		return permissions.Vhost == vhost.Name
+	})
+
+	return nil
+}
+
+// DeletePolicy deletes the given policy. See Provider.DeletePolicy for details.
+func (m *InMemoryProvider) DeletePolicy(policy Policy) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i, existing := range m.policies {
+		if existing.Name == policy.Name && existing.Operator == policy.Operator {
+			m.policies = append(m.policies[:i], m.policies[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("deleting policy: %w", memoryError(ErrNotFound, "no policy '%s' in vhost '/'", policy.Name))
+}
+
+// Connect simulates a client connection with the given channels and consumers. The
+// connection name is set for all channels and consumers, and the channel and consumer
+// counts are derived from them.
+func (m *InMemoryProvider) Connect(connection Connection, channels []Channel, consumers []Consumer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	connection.Channels = len(channels)
+	m.connections = append(m.connections, connection)
+
+	for _, channel := range channels {
+		channel.Connection = connection.Name
+		channel.Consumers = 0
+
+		for _, consumer := range consumers {
+			if consumer.Channel == channel.Name {
+				channel.Consumers++
+			}
+		}
+
+		m.channels = append(m.channels, channel)
+	}
+
+	for _, consumer := range consumers {
+		consumer.Connection = connection.Name
+		m.consumers = append(m.consumers, consumer)
+	}
+}
+
+// GetConnections returns connections passing the filter. See Provider.GetConnections
+// for details.
+func (m *InMemoryProvider) GetConnections(filter func(connection Connection) bool) ([]Connection, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var connections []Connection
+
+	for _, connection := range m.connections {
+		if filter(connection) {
+			connections = append(connections, connection)
+		}
+	}
+
+	return connections, nil
+}
+
+// GetChannels returns channels passing the filter. See Provider.GetChannels for details.
+func (m *InMemoryProvider) GetChannels(filter func(channel Channel) bool) ([]Channel, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var channels []Channel
+
+	for _, channel := range m.channels {
+		if filter(channel) {
+			channels = append(channels, channel)
+		}
+	}
+
+	return channels, nil
+}
+
+// CloseConnection closes the given connection along with its channels and consumers.
+// See Provider.CloseConnection for details.
+func (m *InMemoryProvider) CloseConnection(connection Connection, reason string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i, existing := range m.connections {
+		if existing.Name != connection.Name {
+			continue
+		}
+
+		m.connections = append(m.connections[:i], m.connections[i+1:]...)
+
+		var channels []Channel
+
+		for _, channel := range m.channels {
+			if channel.Connection != connection.Name {
+				channels = append(channels, channel)
+			}
+		}
+
+		m.channels = channels
+
+		m.removeConsumers(func(consumer Consumer) bool {
+			return consumer.Connection == connection.Name
+		})
+
+		return nil
+	}
+
+	return fmt.Errorf("closing connection: %w", memoryError(ErrNotFound, "no connection '%s'", connection.Name))
+}
+
+// GetConsumers returns consumers passing the filter. See Provider.GetConsumers for
+// details.
+func (m *InMemoryProvider) GetConsumers(filter func(consumer Consumer) bool) ([]Consumer, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var consumers []Consumer
+
+	for _, consumer := range m.consumers {
+		if filter(consumer) {
+			consumers = append(consumers, consumer)
+		}
+	}
+
+	return consumers, nil
+}
+
+// queueConsumers returns the number of consumers of the given queue. The caller has
+// to hold the lock.
+func (m *InMemoryProvider) queueConsumers(queue string) int {
+	count := 0
+
+	for _, consumer := range m.consumers {
+		if consumer.Queue == queue {
+			count++
+		}
+	}
+
+	return count
+}
+
+// removeConsumers removes all consumers for which remove returns true. The caller has
+// to hold the lock.
+func (m *InMemoryProvider) removeConsumers(remove func(consumer Consumer) bool) {
+	var consumers []Consumer
+
+	for _, consumer := range m.consumers {
+		if !remove(consumer) {
+			consumers = append(consumers, consumer)
+		}
+	}
+
+	m.consumers = consumers
+}
+
+// GetStatus returns the status of a healthy single-node cluster. See Provider.GetStatus
+// for details.
+func (m *InMemoryProvider) GetStatus() (*Status, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	status := &Status{
+		ClusterName:     "rabbit@localhost",
+		RabbitMQVersion: "in-memory",
+		Nodes: []Node{
+			{Name: "rabbit@localhost", Running: true},
+		},
+	}
+
+	for _, q := range m.queues {
+		status.Messages += len(q.messages)
+		status.MessagesReady += len(q.messages)
+	}
+
+	return status, nil
+}
+
+// exists determines whether the target of a binding with the given type exists. The
+// caller has to hold the lock.
+func (m *InMemoryProvider) exists(bindingType BindingType, name string) bool {
+	if bindingType == ToExchange {
+		_, ok := m.exchanges[name]
+		return ok
+	}
+
+	_, ok := m.queues[name]
+	return ok
+}
+
+// removeBindings removes all bindings for which remove returns true. The caller has to
+// hold the lock.
+func (m *InMemoryProvider) removeBindings(remove func(binding Binding) bool) {
+	var bindings []Binding
+
+	for _, binding := range m.bindings {
+		if !remove(binding) {
+			bindings = append(bindings, binding)
+		}
+	}
+
+	m.bindings = bindings
+}
+
+// removePermissions removes all permissions for which remove returns true. The caller
+// has to hold the lock.
+func (m *InMemoryProvider) removePermissions(remove func(permissions Permissions) bool) {
+	var permissions []Permissions
+
+	for _, p := range m.permissions {
+		if !remove(p) {
+			permissions = append(permissions, p)
+		}
+	}
+
+	m.permissions = permissions
+}
+
+// effectivePolicy returns the name of the regular policy with the highest priority
+// applying to the queue with the given name. The caller has to hold the lock.
+func (m *InMemoryProvider) effectivePolicy(queue string) string {
+	var (
+		name     string
+		priority int
+	)
+
+	for _, policy := range m.policies {
+		if policy.Operator || policy.ApplyTo == "exchanges" {
+			continue
+		}
+
+		if matched, _ := regexp.MatchString(policy.Pattern, queue); !matched {
+			continue
+		}
+
+		if name == "" || policy.Priority > priority {
+			name, priority = policy.Name, policy.Priority
+		}
+	}
+
+	return name
+}
+
+// matcher returns a function that determines whether a resource name passes the name
+// filter of the query. See ListQuery.Name for details.
+func (q ListQuery) matcher() (func(name string) bool, error) {
+	if q.Name == "" {
+		return func(string) bool { return true }, nil
+	}
+
+	if q.Regex {
+		pattern, err := regexp.Compile(q.Name)
+		if err != nil {
+			return nil, err
+		}
+		return pattern.MatchString, nil
+	}
+
+	substring := strings.ToLower(q.Name)
+
+	return func(name string) bool {
+		return strings.Contains(strings.ToLower(name), substring)
+	}, nil
+}
+
+// sort sorts the given slice of resources according to the query. Without a sort
+// field, the given less function is used.
+func (q ListQuery) sort(resources interface{}, less func(i, j int) bool) error {
+	if q.SortBy == "" {
+		sort.Slice(resources, less)
+		return nil
+	}
+
+	sortBy := q.SortBy
+	if q.Descending {
+		sortBy += ":desc"
+	}
+
+	return SortResources(resources, sortBy)
+}
+
+// pageSize returns the page size of the query, which defaults to maxPageSize.
+func (q ListQuery) pageSize() int {
+	if q.PageSize <= 0 || q.PageSize > maxPageSize {
+		return maxPageSize
+	}
+	return q.PageSize
+}
+
+// equivalentExchange returns an error if the declared exchange differs from the
+// existing exchange, using the same message format as the server.
+func equivalentExchange(existing, declared Exchange) error {
+	return equivalent("exchange", existing.Name, []inequivalentArg{
+		{"type", existing.Type, declared.Type},
+		{"durable", existing.Durable, declared.Durable},
+		{"auto_delete", existing.AutoDelete, declared.AutoDelete},
+		{"internal", existing.Internal, declared.Internal},
+		{"arguments", nonNilArguments(existing.Arguments), nonNilArguments(declared.Arguments)},
+	})
+}
+
+// equivalentQueue returns an error if the declared queue differs from the existing
+// queue, using the same message format as the server.
+func equivalentQueue(existing, declared Queue) error {
+	return equivalent("queue", existing.Name, []inequivalentArg{
+		{"x-queue-type", existing.Type, declared.Type},
+		{"durable", existing.Durable, declared.Durable},
+		{"auto_delete", existing.AutoDelete, declared.AutoDelete},
+		{"exclusive", existing.Exclusive, declared.Exclusive},
+		{"arguments", nonNilArguments(existing.Arguments), nonNilArguments(declared.Arguments)},
+	})
+}
+
+// inequivalentArg is a single setting of a declared resource that is compared to the
+// setting of the existing resource.
+type inequivalentArg struct {
+	name     string
+	current  interface{}
+	received interface{}
+}
+
+// equivalent returns an error for the first argument whose current and received
+// values differ.
+func equivalent(kind, name string, args []inequivalentArg) error {
+	for _, arg := range args {
+		if !reflect.DeepEqual(arg.current, arg.received) {
+			return memoryError(ErrPreconditionFailed, "inequivalent arg '%s' for %s '%s' in vhost '/': received '%v' but current is '%v'",
+				arg.name, kind, name, arg.received, arg.current)
+		}
+	}
+	return nil
+}
+
+// memoryError returns a *ServerError of the given kind with the AMQP reply code the
+// server uses for that kind, so that errors of InMemoryProvider can be matched like
+// the errors of a server.
+func memoryError(kind error, format string, args ...interface{}) error {
+	codes := map[error]int{
+		ErrNotFound:           amqp.NotFound,
+		ErrUnauthorized:       amqp.AccessRefused,
+		ErrPreconditionFailed: amqp.PreconditionFailed,
+	}
+
+	return &ServerError{
+		Kind:   kind,
+		Code:   codes[kind],
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// sameBinding determines whether two bindings are identical.
+func sameBinding(a, b Binding) bool {
+	return a.From.Name == b.From.Name &&
+		a.Type == b.Type &&
+		a.TargetName == b.TargetName &&
+		a.Key == b.Key &&
+		reflect.DeepEqual(nonNilArguments(a.Arguments), nonNilArguments(b.Arguments))
+}
+
+// nonNilArguments returns a copy of the given arguments, which is an empty map instead
+// of nil if there are no arguments.
+func nonNilArguments(arguments map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(arguments))
+
+	for key, value := range arguments {
+		result[key] = value
+	}
+
+	return result
+}
+
+// sortDefinitions sorts the given definitions by their identifying fields, so that the
+// definitions returned by the InMemoryProvider are deterministic.
+func sortDefinitions(definitions []Definition) {
+	sort.Slice(definitions, func(i, j int) bool {
+		return fmt.Sprint(definitions[i]) < fmt.Sprint(definitions[j])
+	})
+}
+
+// definitionString returns the string field with the given key, or an empty string.
+func definitionString(definition Definition, key string) string {
+	value, _ := definition[key].(string)
+	return value
+}
+
+// definitionBool returns the boolean field with the given key, or false.
+func definitionBool(definition Definition, key string) bool {
+	value, _ := definition[key].(bool)
+	return value
+}
+
+// definitionArguments returns the object field with the given key, or nil.
+func definitionArguments(definition Definition, key string) map[string]interface{} {
+	value, _ := definition[key].(map[string]interface{})
+	return nonNilArguments(value)
+}
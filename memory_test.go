@@ -0,0 +1,496 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// declare creates the given exchanges, queues and bindings, failing the test if any
+// of them can't be created.
+func declare(t *testing.T, provider Provider, exchanges []Exchange, queues []Queue, bindings []Binding) {
+	t.Helper()
+
+	for _, exchange := range exchanges {
+		if err := provider.CreateExchange(exchange); err != nil {
+			t.Fatalf("creating exchange %s: %v", exchange.Name, err)
+		}
+	}
+
+	for _, queue := range queues {
+		if _, err := provider.CreateQueue(queue); err != nil {
+			t.Fatalf("creating queue %s: %v", queue.Name, err)
+		}
+	}
+
+	for _, binding := range bindings {
+		if err := provider.CreateBinding(binding); err != nil {
+			t.Fatalf("creating binding %s -> %s: %v", binding.From.Name, binding.TargetName, err)
+		}
+	}
+}
+
+// messageCounts returns the number of messages in each queue.
+func messageCounts(t *testing.T, provider Provider) map[string]int {
+	t.Helper()
+
+	queues, err := provider.GetQueues(func(_ Queue) bool {
+		return true
+	})
+	if err != nil {
+		t.Fatalf("listing queues: %v", err)
+	}
+
+	counts := make(map[string]int)
+
+	for _, queue := range queues {
+		counts[queue.Name] = queue.Messages
+	}
+
+	return counts
+}
+
+func TestInMemoryProvider_PublishMessage(t *testing.T) {
+	testCases := map[string]struct {
+		exchange   Exchange
+		bindings   []Binding
+		routingKey string
+		headers    map[string]interface{}
+		expected   map[string]int
+	}{
+		"direct exchange": {
+			exchange: Exchange{Name: "orders", Type: Direct},
+			bindings: []Binding{
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "a", Key: "created"},
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "b", Key: "deleted"},
+			},
+			routingKey: "created",
+			expected:   map[string]int{"a": 1, "b": 0, "c": 0},
+		},
+		"fanout exchange": {
+			exchange: Exchange{Name: "orders", Type: Fanout},
+			bindings: []Binding{
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "a"},
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "b", Key: "ignored"},
+			},
+			routingKey: "created",
+			expected:   map[string]int{"a": 1, "b": 1, "c": 0},
+		},
+		"topic exchange": {
+			exchange: Exchange{Name: "orders", Type: Topic},
+			bindings: []Binding{
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "a", Key: "order.*.eu"},
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "b", Key: "order.#"},
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "c", Key: "order.*"},
+			},
+			routingKey: "order.created.eu",
+			expected:   map[string]int{"a": 1, "b": 1, "c": 0},
+		},
+		"headers exchange": {
+			exchange: Exchange{Name: "orders", Type: Headers},
+			bindings: []Binding{
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "a", Arguments: map[string]interface{}{
+					"x-match": "all", "region": "eu", "type": "created",
+				}},
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "b", Arguments: map[string]interface{}{
+					"x-match": "any", "region": "us", "type": "created",
+				}},
+				{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "c", Arguments: map[string]interface{}{
+					"region": "us", "type": "created",
+				}},
+			},
+			headers:  map[string]interface{}{"region": "eu", "type": "created"},
+			expected: map[string]int{"a": 1, "b": 1, "c": 0},
+		},
+		"exchange-to-exchange binding": {
+			exchange: Exchange{Name: "orders", Type: Fanout},
+			bindings: []Binding{
+				{Type: ToExchange, From: Exchange{Name: "orders"}, TargetName: "amq.topic"},
+				{Type: ToQueue, From: Exchange{Name: "amq.topic"}, TargetName: "c", Key: "#"},
+			},
+			routingKey: "created",
+			expected:   map[string]int{"a": 0, "b": 0, "c": 1},
+		},
+		"default exchange": {
+			exchange:   Exchange{Name: "", Type: Direct, Durable: true},
+			routingKey: "b",
+			expected:   map[string]int{"a": 0, "b": 1, "c": 0},
+		},
+		"unroutable message": {
+			exchange:   Exchange{Name: "orders", Type: Direct},
+			routingKey: "created",
+			expected:   map[string]int{"a": 0, "b": 0, "c": 0},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			provider := NewInMemoryProvider()
+
+			declare(t, provider, []Exchange{testCase.exchange}, []Queue{
+				{Name: "a"}, {Name: "b"}, {Name: "c"},
+			}, testCase.bindings)
+
+			err := provider.PublishMessage(Message{
+				Target:     testCase.exchange,
+				Headers:    testCase.headers,
+				RoutingKey: testCase.routingKey,
+				Body:       []byte("hello"),
+			})
+			if err != nil {
+				t.Fatalf("publishing message: %v", err)
+			}
+
+			counts := messageCounts(t, provider)
+
+			for queue, expected := range testCase.expected {
+				if counts[queue] != expected {
+					t.Errorf("expected %d messages in queue %s, got %d", expected, queue, counts[queue])
+				}
+			}
+		})
+	}
+}
+
+func TestInMemoryProvider_PublishMessage_Errors(t *testing.T) {
+	provider := NewInMemoryProvider()
+
+	declare(t, provider, []Exchange{{Name: "internal", Type: Fanout, Internal: true}}, nil, nil)
+
+	testCases := map[string]struct {
+		exchange string
+		expected string
+	}{
+		"missing exchange":  {exchange: "missing", expected: "no exchange 'missing'"},
+		"internal exchange": {exchange: "internal", expected: "internal exchange"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := provider.PublishMessage(Message{Target: Exchange{Name: testCase.exchange}})
+			if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+				t.Fatalf("expected error containing %q, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func TestInMemoryProvider_GetMessages(t *testing.T) {
+	provider := NewInMemoryProvider()
+
+	declare(t, provider, nil, []Queue{{Name: "a"}}, nil)
+
+	for _, body := range []string{"1", "2", "3"} {
+		if err := provider.PublishMessage(Message{RoutingKey: "a", Body: []byte(body)}); err != nil {
+			t.Fatalf("publishing message: %v", err)
+		}
+	}
+
+	messages, err := provider.GetMessages(Queue{Name: "a"}, 2, true)
+	if err != nil {
+		t.Fatalf("getting messages: %v", err)
+	}
+
+	if len(messages) != 2 || string(messages[0].Body) != "1" || string(messages[1].Body) != "2" {
+		t.Fatalf("expected messages 1 and 2, got %v", messages)
+	}
+
+	if count := messageCounts(t, provider)["a"]; count != 3 {
+		t.Fatalf("expected requeued messages to remain in the queue, got %d messages", count)
+	}
+
+	messages, err = provider.GetMessages(Queue{Name: "a"}, 2, false)
+	if err != nil {
+		t.Fatalf("getting messages: %v", err)
+	}
+
+	if len(messages) != 2 || string(messages[0].Body) != "1" {
+		t.Fatalf("expected messages 1 and 2 again, got %v", messages)
+	}
+
+	messages, err = provider.GetMessages(Queue{Name: "a"}, 10, false)
+	if err != nil {
+		t.Fatalf("getting messages: %v", err)
+	}
+
+	if len(messages) != 1 || string(messages[0].Body) != "3" {
+		t.Fatalf("expected only message 3 to remain, got %v", messages)
+	}
+
+	if _, err := provider.GetMessages(Queue{Name: "missing"}, 1, true); err == nil {
+		t.Fatal("expected an error for a missing queue")
+	}
+}
+
+func TestInMemoryProvider_GetStreamMessages(t *testing.T) {
+	provider := NewInMemoryProvider()
+
+	declare(t, provider, nil, []Queue{{Name: "stream", Type: Stream, Durable: true}, {Name: "classic"}}, nil)
+
+	before := time.Now()
+
+	for _, body := range []string{"1", "2", "3"} {
+		if err := provider.PublishMessage(Message{RoutingKey: "stream", Body: []byte(body)}); err != nil {
+			t.Fatalf("publishing message: %v", err)
+		}
+	}
+
+	testCases := map[string]struct {
+		offset   interface{}
+		expected string
+	}{
+		"first":     {offset: "first", expected: "123"},
+		"last":      {offset: "last", expected: "3"},
+		"next":      {offset: "next", expected: ""},
+		"numeric":   {offset: int64(1), expected: "23"},
+		"timestamp": {offset: before, expected: "123"},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			messages, err := provider.GetStreamMessages(Queue{Name: "stream"}, testCase.offset, 10)
+			if err != nil {
+				t.Fatalf("getting stream messages: %v", err)
+			}
+
+			var bodies string
+			for _, message := range messages {
+				bodies += string(message.Body)
+			}
+
+			if bodies != testCase.expected {
+				t.Fatalf("expected messages %q, got %q", testCase.expected, bodies)
+			}
+		})
+	}
+
+	if _, err := provider.GetStreamMessages(Queue{Name: "classic"}, "first", 10); err == nil {
+		t.Fatal("expected an error for a classic queue")
+	}
+}
+
+func TestInMemoryProvider_DeclarationConflicts(t *testing.T) {
+	provider := NewInMemoryProvider()
+
+	declare(t, provider,
+		[]Exchange{{Name: "orders", Type: Direct, Durable: true}},
+		[]Queue{{Name: "a", Type: Quorum, Durable: true}},
+		nil,
+	)
+
+	if err := provider.CreateExchange(Exchange{Name: "orders", Type: Direct, Durable: true}); err != nil {
+		t.Errorf("expected an equivalent exchange declaration to succeed, got %v", err)
+	}
+
+	if _, err := provider.CreateQueue(Queue{Name: "a", Type: Quorum, Durable: true}); err != nil {
+		t.Errorf("expected an equivalent queue declaration to succeed, got %v", err)
+	}
+
+	testCases := map[string]struct {
+		declare  func() error
+		expected string
+	}{
+		"exchange type": {
+			declare: func() error {
+				return provider.CreateExchange(Exchange{Name: "orders", Type: Fanout, Durable: true})
+			},
+			expected: "inequivalent arg 'type' for exchange 'orders'",
+		},
+		"exchange durability": {
+			declare: func() error {
+				return provider.CreateExchange(Exchange{Name: "orders", Type: Direct})
+			},
+			expected: "inequivalent arg 'durable' for exchange 'orders'",
+		},
+		"reserved exchange name": {
+			declare: func() error {
+				return provider.CreateExchange(Exchange{Name: "amq.orders", Type: Direct})
+			},
+			expected: "reserved prefix",
+		},
+		"queue type": {
+			declare: func() error {
+				_, err := provider.CreateQueue(Queue{Name: "a", Type: Classic, Durable: true})
+				return err
+			},
+			expected: "inequivalent arg 'x-queue-type' for queue 'a'",
+		},
+		"queue arguments": {
+			declare: func() error {
+				_, err := provider.CreateQueue(Queue{Name: "a", Type: Quorum, Durable: true, Arguments: map[string]interface{}{
+					"x-max-length": 10,
+				}})
+				return err
+			},
+			expected: "inequivalent arg 'arguments' for queue 'a'",
+		},
+		"binding to missing queue": {
+			declare: func() error {
+				return provider.CreateBinding(Binding{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "missing"})
+			},
+			expected: "no queue 'missing'",
+		},
+		"binding from missing exchange": {
+			declare: func() error {
+				return provider.CreateBinding(Binding{Type: ToQueue, From: Exchange{Name: "missing"}, TargetName: "a"})
+			},
+			expected: "no exchange 'missing'",
+		},
+		"binding from default exchange": {
+			declare: func() error {
+				return provider.CreateBinding(Binding{Type: ToQueue, From: Exchange{Name: ""}, TargetName: "a"})
+			},
+			expected: "default exchange",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := testCase.declare()
+			if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+				t.Fatalf("expected error containing %q, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func TestInMemoryProvider_Delete(t *testing.T) {
+	provider := NewInMemoryProvider()
+
+	declare(t, provider,
+		[]Exchange{{Name: "orders", Type: Fanout}, {Name: "unused", Type: Fanout}},
+		[]Queue{{Name: "a"}, {Name: "b"}},
+		[]Binding{{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "a"}},
+	)
+
+	if err := provider.PublishMessage(Message{Target: Exchange{Name: "orders"}}); err != nil {
+		t.Fatalf("publishing message: %v", err)
+	}
+
+	if err := provider.DeleteExchange(Exchange{Name: "orders"}, DeleteConditions{IfUnused: true}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("expected deleting an exchange with bindings to fail with if-unused, got %v", err)
+	}
+
+	if err := provider.DeleteExchange(Exchange{Name: "unused"}, DeleteConditions{IfUnused: true}); err != nil {
+		t.Errorf("expected deleting an unused exchange to succeed, got %v", err)
+	}
+
+	if err := provider.DeleteExchange(Exchange{Name: "amq.topic"}, DeleteConditions{}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected deleting a pre-defined exchange to fail, got %v", err)
+	}
+
+	if err := provider.DeleteQueue(Queue{Name: "a"}, DeleteConditions{IfEmpty: true}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("expected deleting a queue with messages to fail with if-empty, got %v", err)
+	}
+
+	if err := provider.DeleteQueue(Queue{Name: "b"}, DeleteConditions{IfEmpty: true}); err != nil {
+		t.Errorf("expected deleting an empty queue to succeed, got %v", err)
+	}
+
+	if err := provider.DeleteQueue(Queue{Name: "a"}, DeleteConditions{}); err != nil {
+		t.Errorf("expected deleting a queue to succeed, got %v", err)
+	}
+
+	if err := provider.DeleteQueue(Queue{Name: "a"}, DeleteConditions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleting a missing queue to fail, got %v", err)
+	}
+
+	bindings, err := provider.GetBindings(func(binding Binding) bool {
+		return binding.From.Name == "orders"
+	})
+	if err != nil {
+		t.Fatalf("listing bindings: %v", err)
+	}
+
+	if len(bindings) != 0 {
+		t.Errorf("expected the bindings of the deleted queue to be deleted, got %v", bindings)
+	}
+}
+
+func TestInMemoryProvider_Connections(t *testing.T) {
+	provider := NewInMemoryProvider()
+
+	declare(t, provider, nil, []Queue{{Name: "orders"}}, nil)
+
+	provider.Connect(
+		Connection{Name: "client"},
+		[]Channel{{Name: "client (1)"}, {Name: "client (2)"}},
+		[]Consumer{{Tag: "ctag-1", Queue: "orders", Channel: "client (1)"}},
+	)
+
+	connections, _ := provider.GetConnections(func(_ Connection) bool { return true })
+	channels, _ := provider.GetChannels(func(_ Channel) bool { return true })
+	consumers, _ := provider.GetConsumers(func(_ Consumer) bool { return true })
+
+	if len(connections) != 1 || connections[0].Channels != 2 {
+		t.Fatalf("expected one connection with two channels, got %v", connections)
+	}
+
+	if len(channels) != 2 || channels[0].Connection != "client" || channels[0].Consumers != 1 || channels[1].Consumers != 0 {
+		t.Fatalf("expected two channels of the connection, got %v", channels)
+	}
+
+	if len(consumers) != 1 || consumers[0].Connection != "client" {
+		t.Fatalf("expected one consumer of the connection, got %v", consumers)
+	}
+
+	if err := provider.DeleteQueue(Queue{Name: "orders"}, DeleteConditions{IfUnused: true}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("expected deleting a queue with consumers to fail with if-unused, got %v", err)
+	}
+
+	if err := provider.CloseConnection(Connection{Name: "client"}, ""); err != nil {
+		t.Fatalf("closing connection: %v", err)
+	}
+
+	channels, _ = provider.GetChannels(func(_ Channel) bool { return true })
+	consumers, _ = provider.GetConsumers(func(_ Consumer) bool { return true })
+
+	if len(channels) != 0 || len(consumers) != 0 {
+		t.Errorf("expected closing the connection to close its channels and consumers, got %v, %v", channels, consumers)
+	}
+
+	if err := provider.DeleteQueue(Queue{Name: "orders"}, DeleteConditions{IfUnused: true}); err != nil {
+		t.Errorf("expected deleting a queue without consumers to succeed, got %v", err)
+	}
+
+	if err := provider.CloseConnection(Connection{Name: "client"}, ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected closing a missing connection to fail, got %v", err)
+	}
+}
+
+func TestInMemoryProvider_Definitions(t *testing.T) {
+	source := NewInMemoryProvider()
+
+	declare(t, source,
+		[]Exchange{{Name: "orders", Type: Topic, Durable: true}},
+		[]Queue{{Name: "a", Type: Quorum, Durable: true}},
+		[]Binding{{Type: ToQueue, From: Exchange{Name: "orders"}, TargetName: "a", Key: "order.#"}},
+	)
+
+	definitions, err := source.GetDefinitions("")
+	if err != nil {
+		t.Fatalf("getting definitions: %v", err)
+	}
+
+	target := NewInMemoryProvider()
+
+	if err := target.ImportDefinitions("", definitions); err != nil {
+		t.Fatalf("importing definitions: %v", err)
+	}
+
+	if err := target.PublishMessage(Message{Target: Exchange{Name: "orders"}, RoutingKey: "order.created"}); err != nil {
+		t.Fatalf("publishing message: %v", err)
+	}
+
+	queues, err := target.GetQueues(func(queue Queue) bool {
+		return queue.Name == "a"
+	})
+	if err != nil {
+		t.Fatalf("listing queues: %v", err)
+	}
+
+	if len(queues) != 1 || queues[0].Type != Quorum || queues[0].Messages != 1 {
+		t.Fatalf("expected the imported quorum queue a with one message, got %v", queues)
+	}
+}
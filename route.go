@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RouteResult is a queue that would receive a message, along with the bindings the
+// message would take from the target exchange to the queue.
+type RouteResult struct {
+
+	// Queue is the name of the queue that would receive the message.
+	Queue string
+
+	// Path contains all steps the message would take, starting at the target exchange.
+	// For messages sent to the default exchange, the path is empty.
+	Path []RouteStep
+}
+
+// RouteStep is a single step of a message on its way from the target exchange to a
+// queue, either following a binding or being passed to an alternate exchange.
+type RouteStep struct {
+
+	// Binding is the binding the message follows. If the message is passed to an
+	// alternate exchange, this is a binding from the exchange to the alternate
+	// exchange without routing key.
+	Binding Binding
+
+	// Alternate indicates that the message is passed to the alternate exchange of an
+	// exchange, because that exchange couldn't route the message.
+	Alternate bool
+}
+
+// Route determines which queues would receive a message with the given routing key
+// and headers when publishing it to the given exchange. Nothing will be published,
+// instead, the routing is evaluated locally using the exchanges and bindings read
+// from the provider.
+//
+// Exchange-to-exchange bindings are followed recursively. In case an exchange can't
+// route the message and has an alternate exchange, the alternate exchange is used.
+// Each queue is only reported once, even if it's reachable via multiple paths.
+//
+// Exchanges are identified by their names, so the provider has to be configured with
+// a single virtual host.
+func Route(provider Provider, exchange, routingKey string, headers map[string]interface{}) ([]RouteResult, error) {
+	// The default exchange implicitly routes messages to the queue whose name equals
+	// the routing key, so there's no need to look at any bindings.
+	if exchange == "" {
+		queues, err := provider.GetQueues(func(queue Queue) bool {
+			return queue.Name == routingKey
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		results := []RouteResult{}
+
+		if len(queues) > 0 {
+			results = append(results, RouteResult{Queue: routingKey})
+		}
+
+		return results, nil
+	}
+
+	exchanges, err := provider.GetExchanges(func(_ Exchange) bool {
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bindings, err := provider.GetBindings(func(binding Binding) bool {
+		return binding.From.Name != ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := router{
+		exchanges:  make(map[string]Exchange),
+		bindings:   bindings,
+		routingKey: routingKey,
+		headers:    headers,
+		visited:    make(map[string]bool),
+		queues:     make(map[string]bool),
+		results:    []RouteResult{},
+	}
+
+	for _, e := range exchanges {
+		r.exchanges[e.Name] = e
+	}
+
+	if _, ok := r.exchanges[exchange]; !ok {
+		return nil, fmt.Errorf("exchange %s not found", exchange)
+	}
+
+	r.route(exchange, nil)
+
+	return r.results, nil
+}
+
+// router holds the state for evaluating the routing of a single message.
+type router struct {
+	exchanges  map[string]Exchange
+	bindings   []Binding
+	routingKey string
+	headers    map[string]interface{}
+	visited    map[string]bool
+	queues     map[string]bool
+	results    []RouteResult
+}
+
+// route routes the message from the given exchange, where path is the list of steps
+// that led to that exchange. Each exchange is only visited once to avoid cycles.
+func (r *router) route(exchange string, path []RouteStep) {
+	if r.visited[exchange] {
+		return
+	}
+	r.visited[exchange] = true
+
+	routed := false
+
+	for _, binding := range r.bindings {
+		if binding.From.Name != exchange || !r.matches(r.exchanges[exchange], binding) {
+			continue
+		}
+
+		routed = true
+		bindingPath := append(append([]RouteStep{}, path...), RouteStep{Binding: binding})
+
+		if binding.Type == ToExchange {
+			r.route(binding.TargetName, bindingPath)
+			continue
+		}
+
+		if r.queues[binding.TargetName] {
+			continue
+		}
+		r.queues[binding.TargetName] = true
+
+		r.results = append(r.results, RouteResult{
+			Queue: binding.TargetName,
+			Path:  bindingPath,
+		})
+	}
+
+	if routed {
+		return
+	}
+
+	if alternate, ok := r.exchanges[exchange].Arguments["alternate-exchange"].(string); ok {
+		step := RouteStep{
+			Binding: Binding{
+				Type:       ToExchange,
+				From:       Exchange{Name: exchange},
+				TargetName: alternate,
+			},
+			Alternate: true,
+		}
+
+		r.route(alternate, append(append([]RouteStep{}, path...), step))
+	}
+}
+
+// matches determines whether the given binding of the given exchange matches the
+// message, depending on the routing semantics of the exchange type.
+func (r *router) matches(exchange Exchange, binding Binding) bool {
+	switch exchange.Type {
+	case Direct:
+		return binding.Key == r.routingKey
+	case Fanout:
+		return true
+	case Topic:
+		return matchTopic(strings.Split(binding.Key, "."), strings.Split(r.routingKey, "."))
+	case Headers:
+		return matchHeaders(binding.Arguments, r.headers)
+	}
+	return false
+}
+
+// matchTopic determines whether the words of a routing key match the words of a topic
+// binding pattern. `*` substitutes exactly one word, `#` substitutes zero or more words.
+func matchTopic(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if matchTopic(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && matchTopic(pattern[1:], words[1:])
+	}
+
+	return len(words) > 0 && pattern[0] == words[0] && matchTopic(pattern[1:], words[1:])
+}
+
+// matchHeaders determines whether the message headers match the arguments of a headers
+// exchange binding. With `x-match` set to `all`, which is the default, all arguments
+// have to be present in the headers. With `any`, a single matching header suffices.
+//
+// Arguments starting with `x-` aren't taken into account, unless `x-match` is set to
+// `all-with-x` or `any-with-x`. Values are compared by their string representation.
+func matchHeaders(arguments, headers map[string]interface{}) bool {
+	mode, _ := arguments["x-match"].(string)
+	if mode == "" {
+		mode = "all"
+	}
+
+	var (
+		withX    = strings.HasSuffix(mode, "-with-x")
+		matchAny = strings.HasPrefix(mode, "any")
+	)
+
+	for key, expected := range arguments {
+		if key == "x-match" || (strings.HasPrefix(key, "x-") && !withX) {
+			continue
+		}
+
+		actual, ok := headers[key]
+		matched := ok && (expected == nil || fmt.Sprint(actual) == fmt.Sprint(expected))
+
+		if matched && matchAny {
+			return true
+		}
+		if !matched && !matchAny {
+			return false
+		}
+	}
+
+	// At this point, either all arguments have matched in `all` mode, or none of them
+	// has matched in `any` mode. This also applies to bindings without arguments.
+	return !matchAny
+}
@@ -0,0 +1,120 @@
+package main
+
+// Status represents an overview of a RabbitMQ cluster, including its nodes, global
+// message statistics and the results of the server-side health checks.
+type Status struct {
+
+	// ClusterName is the name of the cluster, which defaults to the first node name.
+	ClusterName string
+
+	// RabbitMQVersion is the RabbitMQ version of the node serving the API.
+	RabbitMQVersion string
+
+	// ErlangVersion is the Erlang/OTP version of the node serving the API.
+	ErlangVersion string
+
+	// Messages is the total amount of messages in all queues.
+	Messages int
+
+	// MessagesReady is the amount of messages ready to be delivered.
+	MessagesReady int
+
+	// MessagesUnAck is the amount of messages waiting for an acknowledgement.
+	MessagesUnAck int
+
+	// PublishRate is the amount of messages published per second.
+	PublishRate float64
+
+	// DeliverRate is the amount of messages delivered or fetched per second.
+	DeliverRate float64
+
+	// AckRate is the amount of messages acknowledged per second.
+	AckRate float64
+
+	// Nodes contains all nodes of the cluster.
+	Nodes []Node
+
+	// HealthChecks contains the results of all health checks supported by the server.
+	HealthChecks []HealthCheck
+}
+
+// Node represents a single RabbitMQ node within a cluster.
+type Node struct {
+
+	// Name is the name of the node, e.g. `rabbit@localhost`.
+	Name string
+
+	// Running indicates whether the node is running.
+	Running bool
+
+	// MemoryUsed is the amount of memory used by the node in bytes.
+	MemoryUsed int64
+
+	// MemoryLimit is the memory high watermark in bytes. Publishers are blocked once
+	// the used memory exceeds it.
+	MemoryLimit int64
+
+	// MemoryAlarm indicates whether the memory alarm is in effect.
+	MemoryAlarm bool
+
+	// DiskFree is the amount of free disk space in bytes.
+	DiskFree int64
+
+	// DiskFreeLimit is the free disk space limit in bytes. Publishers are blocked once
+	// the free disk space drops below it.
+	DiskFreeLimit int64
+
+	// DiskFreeAlarm indicates whether the free disk space alarm is in effect.
+	DiskFreeAlarm bool
+
+	// FileDescriptorsUsed is the amount of file descriptors used by the node.
+	FileDescriptorsUsed int
+
+	// FileDescriptorsTotal is the amount of file descriptors available to the node.
+	FileDescriptorsTotal int
+
+	// Partitions contains the names of all nodes this node can't communicate with.
+	// A non-empty list indicates a network partition.
+	Partitions []string
+}
+
+// HealthCheck is the result of a single server-side health check.
+type HealthCheck struct {
+
+	// Name is the name of the health check endpoint, e.g. `alarms`.
+	Name string
+
+	// Passed indicates whether the health check has passed.
+	Passed bool
+
+	// Reason describes why the health check has failed.
+	Reason string
+}
+
+// healthChecks lists the health check endpoints below /api/health/checks that
+// are queried for the status. Endpoints unknown to the server are skipped.
+var healthChecks = []string{
+	"alarms",
+	"local-alarms",
+	"virtual-hosts",
+	"node-is-quorum-critical",
+	"node-is-mirror-sync-critical",
+}
+
+// Alarms determines whether a memory or disk alarm is active on any node, or whether
+// any node is partitioned or not running. Failed health checks count as alarm, too.
+func (s *Status) Alarms() bool {
+	for _, node := range s.Nodes {
+		if !node.Running || node.MemoryAlarm || node.DiskFreeAlarm || len(node.Partitions) > 0 {
+			return true
+		}
+	}
+
+	for _, check := range s.HealthChecks {
+		if !check.Passed {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Topology represents the exchanges, queues and bindings of a RabbitMQ server in a
+// portable form. It only contains the definitions of those resources and no runtime
+// data like message counts, so that it can be stored and applied to a server again.
+type Topology struct {
+	Exchanges []TopologyExchange `yaml:"exchanges"`
+	Queues    []TopologyQueue    `yaml:"queues"`
+	Bindings  []TopologyBinding  `yaml:"bindings"`
+}
+
+// TopologyExchange is the definition of an exchange within a Topology.
+type TopologyExchange struct {
+	Name       string                 `yaml:"name"`
+	Type       ExchangeType           `yaml:"type"`
+	Durable    bool                   `yaml:"durable"`
+	AutoDelete bool                   `yaml:"auto_delete"`
+	Internal   bool                   `yaml:"internal"`
+	Arguments  map[string]interface{} `yaml:"arguments,omitempty"`
+}
+
+// TopologyQueue is the definition of a queue within a Topology.
+type TopologyQueue struct {
+	Name       string                 `yaml:"name"`
+	Type       QueueType              `yaml:"type"`
+	Durable    bool                   `yaml:"durable"`
+	AutoDelete bool                   `yaml:"auto_delete"`
+	Arguments  map[string]interface{} `yaml:"arguments,omitempty"`
+}
+
+// TopologyBinding is the definition of a binding within a Topology.
+type TopologyBinding struct {
+	Source          string                 `yaml:"source"`
+	Destination     string                 `yaml:"destination"`
+	DestinationType BindingType            `yaml:"destination_type"`
+	RoutingKey      string                 `yaml:"routing_key"`
+	Arguments       map[string]interface{} `yaml:"arguments,omitempty"`
+}
+
+// ExportTopology reads the exchanges, queues and bindings from the given provider and
+// returns them as Topology. Unless includeSystem is true, all resources managed by the
+// server itself are skipped - see isSystemExchange and isSystemQueue for details.
+//
+// Bindings from the default exchange are always skipped since the server creates them
+// implicitly for each queue and they can't be declared by clients.
+func ExportTopology(provider Provider, includeSystem bool) (*Topology, error) {
+	exchanges, err := provider.GetExchanges(func(exchange Exchange) bool {
+		return includeSystem || !isSystemExchange(exchange)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queues, err := provider.GetQueues(func(queue Queue) bool {
+		return includeSystem || !isSystemQueue(queue)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep track of the exported resources. Bindings are only exported if both
+	// their source and their destination are part of the topology as well.
+	exported := make(map[string]bool)
+
+	topology := &Topology{
+		Exchanges: []TopologyExchange{},
+		Queues:    []TopologyQueue{},
+		Bindings:  []TopologyBinding{},
+	}
+
+	for _, exchange := range exchanges {
+		topology.Exchanges = append(topology.Exchanges, TopologyExchange{
+			Name:       exchange.Name,
+			Type:       exchange.Type,
+			Durable:    exchange.Durable,
+			AutoDelete: exchange.AutoDelete,
+			Internal:   exchange.Internal,
+			Arguments:  exchange.Arguments,
+		})
+		exported[resourceKey(ToExchange, exchange.Name)] = true
+	}
+
+	for _, queue := range queues {
+		topology.Queues = append(topology.Queues, TopologyQueue{
+			Name:       queue.Name,
+			Type:       queue.Type,
+			Durable:    queue.Durable,
+			AutoDelete: queue.AutoDelete,
+			Arguments:  queue.Arguments,
+		})
+		exported[resourceKey(ToQueue, queue.Name)] = true
+	}
+
+	bindings, err := provider.GetBindings(func(binding Binding) bool {
+		return binding.From.Name != "" &&
+			exported[resourceKey(ToExchange, binding.From.Name)] &&
+			exported[resourceKey(binding.Type, binding.TargetName)]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, binding := range bindings {
+		topology.Bindings = append(topology.Bindings, TopologyBinding{
+			Source:          binding.From.Name,
+			Destination:     binding.TargetName,
+			DestinationType: binding.Type,
+			RoutingKey:      binding.Key,
+			Arguments:       binding.Arguments,
+		})
+	}
+
+	return topology, nil
+}
+
+// isSystemExchange determines whether the given exchange is managed by the server.
+// This applies to the default exchange and all pre-defined `amq.*` exchanges.
+func isSystemExchange(exchange Exchange) bool {
+	return exchange.Name == "" || strings.HasPrefix(exchange.Name, "amq.")
+}
+
+// isSystemQueue determines whether the given queue is managed by the server or tied
+// to a single connection. This applies to server-named and exclusive queues.
+func isSystemQueue(queue Queue) bool {
+	return strings.HasPrefix(queue.Name, "amq.") || queue.Exclusive
+}
+
+// resourceKey returns a key that uniquely identifies an exchange or a queue, since
+// an exchange and a queue may have the same name.
+func resourceKey(resourceType BindingType, name string) string {
+	return fmt.Sprintf("%s/%s", resourceType, name)
+}
+
+// Filter removes all exchanges and queues from the topology whose names don't pass
+// the given match function. Bindings whose source or destination has been removed
+// will be removed as well.
+func (t *Topology) Filter(match func(name string) bool) {
+	kept := make(map[string]bool)
+
+	exchanges := []TopologyExchange{}
+
+	for _, exchange := range t.Exchanges {
+		if match(exchange.Name) {
+			exchanges = append(exchanges, exchange)
+			kept[resourceKey(ToExchange, exchange.Name)] = true
+		}
+	}
+
+	queues := []TopologyQueue{}
+
+	for _, queue := range t.Queues {
+		if match(queue.Name) {
+			queues = append(queues, queue)
+			kept[resourceKey(ToQueue, queue.Name)] = true
+		}
+	}
+
+	bindings := []TopologyBinding{}
+
+	for _, binding := range t.Bindings {
+		if kept[resourceKey(ToExchange, binding.Source)] &&
+			kept[resourceKey(binding.DestinationType, binding.Destination)] {
+			bindings = append(bindings, binding)
+		}
+	}
+
+	t.Exchanges = exchanges
+	t.Queues = queues
+	t.Bindings = bindings
+}
+
+// ApplyTopology declares all exchanges, queues and bindings of the given topology
+// using the given provider. Exchanges and queues are declared first, so that they
+// exist once the bindings between them are created.
+//
+// Since declaring an existing resource has no effect, ApplyTopology can be called
+// multiple times for the same topology.
+func ApplyTopology(provider Provider, topology *Topology) error {
+	for _, exchange := range topology.Exchanges {
+		if err := provider.CreateExchange(exchange.toExchange()); err != nil {
+			return fmt.Errorf("exchange %s: %w", exchange.Name, err)
+		}
+	}
+
+	for _, queue := range topology.Queues {
+		if _, err := provider.CreateQueue(queue.toQueue()); err != nil {
+			return fmt.Errorf("queue %s: %w", queue.Name, err)
+		}
+	}
+
+	for _, binding := range topology.Bindings {
+		if err := provider.CreateBinding(binding.toBinding()); err != nil {
+			return fmt.Errorf("binding %s -> %s: %w", binding.Source, binding.Destination, err)
+		}
+	}
+
+	return nil
+}
+
+// toExchange converts the exchange definition into an Exchange.
+func (e TopologyExchange) toExchange() Exchange {
+	return Exchange{
+		Name:       e.Name,
+		Type:       e.Type,
+		Durable:    e.Durable,
+		AutoDelete: e.AutoDelete,
+		Internal:   e.Internal,
+		Arguments:  e.Arguments,
+	}
+}
+
+// toQueue converts the queue definition into a Queue.
+func (q TopologyQueue) toQueue() Queue {
+	return Queue{
+		Name:       q.Name,
+		Type:       q.Type,
+		Durable:    q.Durable,
+		AutoDelete: q.AutoDelete,
+		Arguments:  q.Arguments,
+	}
+}
+
+// toBinding converts the binding definition into a Binding.
+func (b TopologyBinding) toBinding() Binding {
+	return Binding{
+		Type:       b.DestinationType,
+		From:       Exchange{Name: b.Source},
+		TargetName: b.Destination,
+		Key:        b.RoutingKey,
+		Arguments:  b.Arguments,
+	}
+}
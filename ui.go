@@ -0,0 +1,487 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// uiTab represents a tab of the terminal UI, each of them listing one resource kind.
+type uiTab int
+
+const (
+	exchangesTab uiTab = iota
+	queuesTab
+	bindingsTab
+	connectionsTab
+)
+
+// uiTabTitles contains the titles of all tabs in the order they're displayed.
+var uiTabTitles = []string{"Exchanges", "Queues", "Bindings", "Connections"}
+
+// uiHelp is the key binding help displayed at the bottom of the screen.
+const uiHelp = "[yellow]Tab[-] switch  [yellow]/[-] filter  [yellow]Enter[-] messages  " +
+	"[yellow]p[-] publish  [yellow]x[-] purge  [yellow]d[-] delete/close  [yellow]r[-] refresh  [yellow]q[-] quit"
+
+// terminalUI is a full-screen terminal UI for browsing a RabbitMQ server. It only uses
+// the Provider methods, so that it behaves exactly like the corresponding commands.
+//
+// The resources are read synchronously when switching tabs or refreshing, which keeps
+// the UI consistent with the server at the expense of a short delay for each action.
+type terminalUI struct {
+	provider Provider
+	app      *tview.Application
+	pages    *tview.Pages
+	tabBar   *tview.TextView
+	table    *tview.Table
+	filter   *tview.InputField
+	status   *tview.TextView
+	tab      uiTab
+
+	// rows contains the rows of the current tab, and visible contains those rows that
+	// pass the filter. The first column of each row identifies the resource.
+	rows    [][]string
+	visible [][]string
+}
+
+// newTerminalUI creates a new terminal UI for the given provider without running it.
+func newTerminalUI(provider Provider) *terminalUI {
+	ui := &terminalUI{
+		provider: provider,
+		app:      tview.NewApplication(),
+		pages:    tview.NewPages(),
+		tabBar:   tview.NewTextView(),
+		table:    tview.NewTable(),
+		filter:   tview.NewInputField(),
+		status:   tview.NewTextView(),
+	}
+
+	ui.tabBar.
+		SetDynamicColors(true).
+		SetRegions(true)
+
+	ui.table.
+		SetSelectable(true, false).
+		SetFixed(1, 0).
+		SetSelectedFunc(func(row, _ int) {
+			ui.showMessages(row)
+		}).
+		SetInputCapture(ui.handleKey)
+
+	ui.filter.
+		SetLabel("Filter: ").
+		SetChangedFunc(func(_ string) {
+			ui.render()
+		}).
+		SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEscape {
+				ui.filter.SetText("")
+			}
+			ui.app.SetFocus(ui.table)
+		})
+
+	ui.status.SetDynamicColors(true)
+
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(ui.tabBar, 1, 0, false).
+		AddItem(ui.table, 0, 1, true).
+		AddItem(ui.filter, 1, 0, false).
+		AddItem(ui.status, 1, 0, false)
+
+	ui.pages.AddPage("main", layout, true, true)
+	ui.app.SetRoot(ui.pages, true)
+
+	return ui
+}
+
+// run loads the first tab and runs the terminal UI until the user quits.
+func (ui *terminalUI) run() error {
+	ui.switchTab(exchangesTab)
+	return ui.app.Run()
+}
+
+// handleKey handles all key events on the resource table. Events that aren't handled
+// are returned, so that they're processed by the table itself.
+func (ui *terminalUI) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyTab:
+		ui.switchTab((ui.tab + 1) % uiTab(len(uiTabTitles)))
+		return nil
+	case tcell.KeyBacktab:
+		ui.switchTab((ui.tab + uiTab(len(uiTabTitles)) - 1) % uiTab(len(uiTabTitles)))
+		return nil
+	}
+
+	switch event.Rune() {
+	case '1', '2', '3', '4':
+		ui.switchTab(uiTab(event.Rune() - '1'))
+	case '/':
+		ui.app.SetFocus(ui.filter)
+	case 'r':
+		ui.refresh()
+	case 'p':
+		ui.showPublishForm()
+	case 'x':
+		ui.purgeSelected()
+	case 'd':
+		ui.deleteSelected()
+	case 'q':
+		ui.app.Stop()
+	default:
+		return event
+	}
+
+	return nil
+}
+
+// switchTab switches to the given tab and loads its resources.
+func (ui *terminalUI) switchTab(tab uiTab) {
+	ui.tab = tab
+
+	var titles []string
+
+	for i, title := range uiTabTitles {
+		titles = append(titles, fmt.Sprintf(`["%d"] %d %s [""]`, i, i+1, title))
+	}
+
+	ui.tabBar.SetText(strings.Join(titles, " "))
+	ui.tabBar.Highlight(strconv.Itoa(int(tab)))
+
+	ui.refresh()
+	ui.table.ScrollToBeginning()
+}
+
+// refresh reads the resources of the current tab from the provider and renders them.
+func (ui *terminalUI) refresh() {
+	rows, err := ui.load()
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+
+	ui.rows = rows
+	ui.render()
+	ui.showInfo(uiHelp)
+}
+
+// load reads the resources of the current tab and converts them into table rows.
+func (ui *terminalUI) load() ([][]string, error) {
+	var rows [][]string
+
+	switch ui.tab {
+	case exchangesTab:
+		exchanges, err := ui.provider.GetExchanges(func(_ Exchange) bool {
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, exchange := range exchanges {
+			rows = append(rows, []string{
+				exchange.Name,
+				string(exchange.Type),
+				boolToString(exchange.Durable),
+				boolToString(exchange.AutoDelete),
+				boolToString(exchange.Internal),
+			})
+		}
+
+	case queuesTab:
+		queues, err := ui.provider.GetQueues(func(_ Queue) bool {
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, queue := range queues {
+			rows = append(rows, []string{
+				queue.Name,
+				string(queue.Type),
+				strconv.Itoa(queue.Messages),
+				strconv.Itoa(queue.MessagesUnAck),
+				strconv.Itoa(queue.Consumers),
+				queue.Policy,
+			})
+		}
+
+	case bindingsTab:
+		bindings, err := ui.provider.GetBindings(func(_ Binding) bool {
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, binding := range bindings {
+			rows = append(rows, []string{
+				binding.From.Name,
+				binding.TargetName,
+				string(binding.Type),
+				binding.Key,
+				argumentsToString(binding.Arguments),
+			})
+		}
+
+	case connectionsTab:
+		connections, err := ui.provider.GetConnections(func(_ Connection) bool {
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, connection := range connections {
+			rows = append(rows, []string{
+				connection.Name,
+				connection.User,
+				connection.Vhost,
+				clientPropertiesToString(connection.ClientProperties),
+				strconv.Itoa(connection.Channels),
+			})
+		}
+	}
+
+	return rows, nil
+}
+
+// header returns the column titles of the current tab.
+func (ui *terminalUI) header() []string {
+	switch ui.tab {
+	case exchangesTab:
+		return []string{"Name", "Type", "Durable", "Auto-Delete", "Internal"}
+	case queuesTab:
+		return []string{"Name", "Type", "Messages", "MessagesUnAck", "Consumers", "Effective Policy"}
+	case bindingsTab:
+		return []string{"From", "Target", "Type", "Binding Key", "Arguments"}
+	case connectionsTab:
+		return []string{"Name", "User", "Vhost", "Client", "Channels"}
+	}
+	return nil
+}
+
+// render renders all rows of the current tab that contain the filter text in any of
+// their columns. The filter is case-insensitive.
+func (ui *terminalUI) render() {
+	filter := strings.ToLower(ui.filter.GetText())
+
+	ui.table.Clear()
+	ui.visible = nil
+
+	for column, title := range ui.header() {
+		ui.table.SetCell(0, column, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+
+	for _, row := range ui.rows {
+		if filter != "" && !strings.Contains(strings.ToLower(strings.Join(row, "\t")), filter) {
+			continue
+		}
+
+		ui.visible = append(ui.visible, row)
+
+		for column, value := range row {
+			ui.table.SetCell(len(ui.visible), column, tview.NewTableCell(tview.Escape(value)))
+		}
+	}
+}
+
+// selected returns the name of the selected resource. If no resource is selected, the
+// returned bool is false.
+func (ui *terminalUI) selected() (string, bool) {
+	row, _ := ui.table.GetSelection()
+
+	if row < 1 || row > len(ui.visible) {
+		return "", false
+	}
+
+	return ui.visible[row-1][0], true
+}
+
+// showMessages peeks at the first messages of the queue in the given table row. The
+// messages are re-queued immediately, but they will be marked as redelivered.
+func (ui *terminalUI) showMessages(row int) {
+	if ui.tab != queuesTab || row < 1 || row > len(ui.visible) {
+		return
+	}
+
+	queue := ui.visible[row-1][0]
+
+	messages, err := ui.provider.GetMessages(Queue{Name: queue}, 20, true)
+	if err != nil {
+		ui.showError(err)
+		return
+	}
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	for column, title := range []string{"Exchange", "Routing Key", "Body"} {
+		table.SetCell(0, column, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+
+	for i, message := range messages {
+		table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(message.Target.Name)))
+		table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(message.RoutingKey)))
+		table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(string(message.Body))))
+	}
+
+	table.
+		SetBorder(true).
+		SetTitle(fmt.Sprintf(" Messages in %s (Esc to close) ", queue))
+
+	table.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			ui.closePage("messages")
+		}
+	})
+
+	ui.pages.AddPage("messages", table, true, true)
+	ui.app.SetFocus(table)
+}
+
+// showPublishForm shows a form for publishing a message. When in the exchanges tab, the
+// selected exchange is used as target. When in the queues tab, the message is sent to
+// the selected queue using the default exchange.
+func (ui *terminalUI) showPublishForm() {
+	var exchange, routingKey string
+
+	if name, ok := ui.selected(); ok {
+		switch ui.tab {
+		case exchangesTab:
+			exchange = name
+		case queuesTab:
+			routingKey = name
+		}
+	}
+
+	form := tview.NewForm().
+		AddInputField("Exchange", exchange, 40, nil, nil).
+		AddInputField("Routing Key", routingKey, 40, nil, nil).
+		AddInputField("Headers", "", 40, nil, nil).
+		AddInputField("Body", "", 40, nil, nil)
+
+	text := func(label string) string {
+		return form.GetFormItemByLabel(label).(*tview.InputField).GetText()
+	}
+
+	form.AddButton("Publish", func() {
+		headers, err := parseHeaders(text("Headers"))
+		if err != nil {
+			ui.showError(err)
+			return
+		}
+
+		message := Message{
+			Target:     Exchange{Name: text("Exchange")},
+			Headers:    headers,
+			RoutingKey: text("Routing Key"),
+			Body:       []byte(text("Body")),
+		}
+
+		if err := ui.provider.PublishMessage(message); err != nil {
+			ui.showError(err)
+			return
+		}
+
+		ui.closePage("publish")
+		ui.refresh()
+		ui.showInfo("message published successfully")
+	})
+
+	form.AddButton("Cancel", func() {
+		ui.closePage("publish")
+	})
+
+	form.
+		SetCancelFunc(func() {
+			ui.closePage("publish")
+		}).
+		SetBorder(true).
+		SetTitle(" Publish a message ")
+
+	ui.pages.AddPage("publish", form, true, true)
+	ui.app.SetFocus(form)
+}
+
+// purgeSelected purges the selected queue after the user has confirmed it.
+func (ui *terminalUI) purgeSelected() {
+	name, ok := ui.selected()
+	if !ok || ui.tab != queuesTab {
+		return
+	}
+
+	ui.confirm(fmt.Sprintf("Purge all ready messages from queue %s?", name), func() error {
+		return ui.provider.PurgeQueue(Queue{Name: name})
+	})
+}
+
+// deleteSelected deletes the selected exchange or queue, or closes the selected
+// connection, after the user has confirmed it.
+func (ui *terminalUI) deleteSelected() {
+	name, ok := ui.selected()
+	if !ok {
+		return
+	}
+
+	switch ui.tab {
+	case exchangesTab:
+		ui.confirm(fmt.Sprintf("Delete exchange %s?", name), func() error {
+			return ui.provider.DeleteExchange(Exchange{Name: name}, DeleteConditions{})
+		})
+	case queuesTab:
+		ui.confirm(fmt.Sprintf("Delete queue %s and all of its messages?", name), func() error {
+			return ui.provider.DeleteQueue(Queue{Name: name}, DeleteConditions{})
+		})
+	case connectionsTab:
+		ui.confirm(fmt.Sprintf("Close connection %s?", name), func() error {
+			return ui.provider.CloseConnection(Connection{Name: name}, "closed by buneary")
+		})
+	}
+}
+
+// confirm shows a modal dialog asking the user to confirm the given message. If the
+// user confirms it, the given action is run and the current tab gets refreshed.
+func (ui *terminalUI) confirm(message string, action func() error) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{"Cancel", "Confirm"}).
+		SetDoneFunc(func(_ int, label string) {
+			ui.closePage("confirm")
+
+			if label != "Confirm" {
+				return
+			}
+
+			if err := action(); err != nil {
+				ui.showError(err)
+				return
+			}
+
+			ui.refresh()
+		})
+
+	ui.pages.AddPage("confirm", modal, true, true)
+	ui.app.SetFocus(modal)
+}
+
+// closePage removes the page with the given name and focuses the resource table.
+func (ui *terminalUI) closePage(name string) {
+	ui.pages.RemovePage(name)
+	ui.app.SetFocus(ui.table)
+}
+
+// showInfo displays the given text in the status line.
+func (ui *terminalUI) showInfo(text string) {
+	ui.status.SetText(text)
+}
+
+// showError displays the given error in the status line.
+func (ui *terminalUI) showError(err error) {
+	ui.status.SetText("[red]" + tview.Escape(err.Error()))
+}